@@ -0,0 +1,110 @@
+package irc
+
+import (
+	"strings"
+	"sync"
+)
+
+// ParseCapChange parses a CAP LS, LIST, ACK, NAK, NEW or DEL message from the
+// server. subcommand is returned in uppercase, and caps maps each capability to
+// its value, or an empty string if it doesn't have one. With CAP LS 302, a
+// value may be sent for capabilities such as sasl, in which case it is
+// returned as-is, for example "PLAIN,EXTERNAL".
+//
+// Capabilities in an ACK are returned with any "-" prefix intact, as it means
+// the capability was disabled. Multi-line LS and LIST replies are sent as
+// multiple messages, so each one needs to be parsed separately. ok will be
+// false if this isn't one of these CAP messages.
+func ParseCapChange(m *Message) (subcommand string, caps map[string]string, ok bool) {
+	if m.Command != "CAP" || len(m.Params) < 3 {
+		return "", nil, false
+	}
+
+	subcommand = strings.ToUpper(m.Params[1])
+	switch subcommand {
+	case "LS", "LIST", "ACK", "NAK", "NEW", "DEL":
+	default:
+		return "", nil, false
+	}
+
+	caps = make(map[string]string)
+	for _, field := range strings.Fields(m.Trailing()) {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) == 2 {
+			caps[parts[0]] = parts[1]
+		} else {
+			caps[parts[0]] = ""
+		}
+	}
+
+	return subcommand, caps, true
+}
+
+// CapSet tracks which capabilities are currently enabled. Enabling or disabling
+// a capability more than once has no additional effect. It is safe for
+// concurrent use.
+type CapSet struct {
+	sync.RWMutex
+
+	enabled map[string]bool
+}
+
+// NewCapSet creates a new CapSet with no capabilities enabled.
+func NewCapSet() *CapSet {
+	return &CapSet{
+		enabled: make(map[string]bool),
+	}
+}
+
+// Enable marks the given capability as enabled.
+func (s *CapSet) Enable(capName string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.enabled[capName] = true
+}
+
+// Disable marks the given capability as disabled.
+func (s *CapSet) Disable(capName string) {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.enabled, capName)
+}
+
+// Has returns true if the given capability is enabled.
+func (s *CapSet) Has(capName string) bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.enabled[capName]
+}
+
+// Apply updates the set from a CAP message. Capabilities in an ACK or LIST are
+// enabled, unless an ACK prefixes them with "-", and capabilities in a DEL are
+// disabled. LS, NEW and NAK messages only announce or reject capabilities, so
+// they don't change the set. All other messages are ignored.
+func (s *CapSet) Apply(m *Message) {
+	subcommand, caps, ok := ParseCapChange(m)
+	if !ok {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	for capName := range caps {
+		switch subcommand {
+		case "ACK":
+			if strings.HasPrefix(capName, "-") {
+				delete(s.enabled, capName[1:])
+			} else {
+				s.enabled[capName] = true
+			}
+		case "LIST":
+			s.enabled[capName] = true
+		case "DEL":
+			delete(s.enabled, capName)
+		}
+	}
+}
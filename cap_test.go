@@ -0,0 +1,109 @@
+package irc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestParseCapChange(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input      string
+		Subcommand string
+		Caps       map[string]string
+		OK         bool
+	}{
+		{
+			Input:      ":irc.example.com CAP lemuria NEW :sasl=PLAIN,EXTERNAL away-notify",
+			Subcommand: "NEW",
+			Caps:       map[string]string{"sasl": "PLAIN,EXTERNAL", "away-notify": ""},
+			OK:         true,
+		},
+		{
+			Input:      ":irc.example.com CAP lemuria DEL :sasl",
+			Subcommand: "DEL",
+			Caps:       map[string]string{"sasl": ""},
+			OK:         true,
+		},
+		{
+			Input:      ":irc.example.com CAP * LS * :multi-prefix sasl=PLAIN draft/example=a=b",
+			Subcommand: "LS",
+			Caps:       map[string]string{"multi-prefix": "", "sasl": "PLAIN", "draft/example": "a=b"},
+			OK:         true,
+		},
+		{
+			Input:      ":irc.example.com CAP lemuria ack :-away-notify sasl",
+			Subcommand: "ACK",
+			Caps:       map[string]string{"-away-notify": "", "sasl": ""},
+			OK:         true,
+		},
+		{
+			Input:      ":irc.example.com CAP lemuria NAK :sasl",
+			Subcommand: "NAK",
+			Caps:       map[string]string{"sasl": ""},
+			OK:         true,
+		},
+		{
+			Input: ":irc.example.com CAP lemuria REQ :sasl",
+		},
+		{
+			Input: ":irc.example.com CAP lemuria",
+		},
+		{
+			Input: ":irc.example.com PRIVMSG lemuria LS :sasl",
+		},
+	}
+
+	for _, testCase := range testCases {
+		subcommand, caps, ok := irc.ParseCapChange(irc.MustParseMessage(testCase.Input))
+		assert.Equal(t, testCase.OK, ok, "ok didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Subcommand, subcommand, "subcommand didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Caps, caps, "caps didn't match for %q", testCase.Input)
+	}
+}
+
+func TestCapSet(t *testing.T) {
+	t.Parallel()
+
+	s := irc.NewCapSet()
+	assert.False(t, s.Has("sasl"))
+
+	s.Enable("sasl")
+	s.Enable("sasl")
+	assert.True(t, s.Has("sasl"))
+
+	s.Disable("sasl")
+	s.Disable("sasl")
+	assert.False(t, s.Has("sasl"))
+
+	// Announcing caps doesn't enable them
+	s.Apply(irc.MustParseMessage(":irc.example.com CAP * LS :sasl=PLAIN multi-prefix away-notify"))
+	s.Apply(irc.MustParseMessage(":irc.example.com CAP lemuria NEW :echo-message"))
+	assert.False(t, s.Has("sasl"))
+	assert.False(t, s.Has("echo-message"))
+
+	s.Apply(irc.MustParseMessage(":irc.example.com CAP lemuria ACK :sasl multi-prefix"))
+	assert.True(t, s.Has("sasl"))
+	assert.True(t, s.Has("multi-prefix"))
+
+	s.Apply(irc.MustParseMessage(":irc.example.com CAP lemuria NAK :away-notify"))
+	assert.False(t, s.Has("away-notify"))
+
+	s.Apply(irc.MustParseMessage(":irc.example.com CAP lemuria DEL :sasl"))
+	assert.False(t, s.Has("sasl"))
+	assert.True(t, s.Has("multi-prefix"))
+
+	s.Apply(irc.MustParseMessage(":irc.example.com CAP lemuria ACK :-multi-prefix"))
+	assert.False(t, s.Has("multi-prefix"))
+
+	s.Apply(irc.MustParseMessage(":irc.example.com CAP lemuria LIST :echo-message"))
+	assert.True(t, s.Has("echo-message"))
+
+	// Other messages shouldn't change anything
+	s.Apply(irc.MustParseMessage(":nick!user@host PRIVMSG lemuria :CAP lemuria DEL :echo-message"))
+	assert.True(t, s.Has("echo-message"))
+}
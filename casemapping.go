@@ -0,0 +1,64 @@
+package irc
+
+import "strings"
+
+// CaseMapping determines which characters are considered equivalent when
+// comparing nicks and channel names, as described by the CASEMAPPING ISUPPORT
+// token.
+type CaseMapping int
+
+const (
+	// CaseMappingRFC1459 treats A-Z as a-z and []\~ as {}|^. This is the
+	// default if the server doesn't specify a mapping.
+	CaseMappingRFC1459 CaseMapping = iota
+
+	// CaseMappingASCII only treats A-Z as a-z.
+	CaseMappingASCII
+
+	// CaseMappingRFC1459Strict treats A-Z as a-z and []\ as {}|.
+	CaseMappingRFC1459Strict
+)
+
+// ToLower folds s to lower case using this mapping. Any characters not covered
+// by the mapping are left alone.
+func (c CaseMapping) ToLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		case c == CaseMappingASCII:
+			return r
+		case r == '[':
+			return '{'
+		case r == ']':
+			return '}'
+		case r == '\\':
+			return '|'
+		case r == '~' && c == CaseMappingRFC1459:
+			return '^'
+		}
+
+		return r
+	}, s)
+}
+
+// NickEqual checks if two nicks (or channel names) are equivalent using the
+// given CaseMapping.
+func NickEqual(a, b string, mapping CaseMapping) bool {
+	return len(a) == len(b) && mapping.ToLower(a) == mapping.ToLower(b)
+}
+
+// CaseMapping returns the CaseMapping from the CASEMAPPING token, falling back
+// to CaseMappingRFC1459 if it is missing or unknown. Both "strict-rfc1459",
+// which is what servers send, and "rfc1459-strict" are accepted for
+// CaseMappingRFC1459Strict.
+func (s ISupport) CaseMapping() CaseMapping {
+	switch s["CASEMAPPING"] {
+	case "ascii":
+		return CaseMappingASCII
+	case "strict-rfc1459", "rfc1459-strict":
+		return CaseMappingRFC1459Strict
+	default:
+		return CaseMappingRFC1459
+	}
+}
@@ -0,0 +1,57 @@
+package irc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestCaseMappingToLower(t *testing.T) {
+	t.Parallel()
+
+	input := "Nick[]\\~Ö"
+
+	assert.Equal(t, "nick{}|^Ö", irc.CaseMappingRFC1459.ToLower(input))
+	assert.Equal(t, "nick{}|~Ö", irc.CaseMappingRFC1459Strict.ToLower(input))
+	assert.Equal(t, "nick[]\\~Ö", irc.CaseMappingASCII.ToLower(input))
+}
+
+func TestNickEqual(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, irc.NickEqual("Nick[1]", "nick{1}", irc.CaseMappingRFC1459))
+	assert.True(t, irc.NickEqual("Nick~", "nick^", irc.CaseMappingRFC1459))
+	assert.False(t, irc.NickEqual("Nick~", "nick^", irc.CaseMappingRFC1459Strict))
+	assert.False(t, irc.NickEqual("Nick[1]", "nick{1}", irc.CaseMappingASCII))
+	assert.True(t, irc.NickEqual("NICK", "nick", irc.CaseMappingASCII))
+	assert.False(t, irc.NickEqual("nick", "nick2", irc.CaseMappingASCII))
+}
+
+func TestISupportCaseMapping(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Supports irc.ISupport
+		Expected irc.CaseMapping
+	}{
+		{irc.ISupport{"CASEMAPPING": "ascii"}, irc.CaseMappingASCII},
+		{irc.ISupport{"CASEMAPPING": "rfc1459"}, irc.CaseMappingRFC1459},
+		{irc.ISupport{"CASEMAPPING": "strict-rfc1459"}, irc.CaseMappingRFC1459Strict},
+		{irc.ISupport{"CASEMAPPING": "rfc1459-strict"}, irc.CaseMappingRFC1459Strict},
+		{irc.ISupport{"CASEMAPPING": "rfc7613"}, irc.CaseMappingRFC1459},
+		{irc.ISupport{"CASEMAPPING": ""}, irc.CaseMappingRFC1459},
+		{irc.ISupport{}, irc.CaseMappingRFC1459},
+		{nil, irc.CaseMappingRFC1459},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.Expected, testCase.Supports.CaseMapping(), "CaseMapping didn't match for %v", testCase.Supports)
+	}
+
+	// The mapping should be usable with NickEqual directly
+	supports := irc.ISupport{"CASEMAPPING": "ascii"}
+	assert.False(t, irc.NickEqual("lemur[]", "LEMUR{}", supports.CaseMapping()))
+	assert.True(t, irc.NickEqual("lemur[]", "LEMUR{}", irc.ISupport{}.CaseMapping()))
+}
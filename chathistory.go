@@ -0,0 +1,112 @@
+package irc
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidChatHistorySelector is returned when building a CHATHISTORY
+	// message if a selector is not a valid timestamp or msgid selector.
+	ErrInvalidChatHistorySelector = errors.New("irc: invalid chathistory selector")
+
+	// ErrInvalidChatHistoryLimit is returned when building a CHATHISTORY
+	// message if the limit is not positive.
+	ErrInvalidChatHistoryLimit = errors.New("irc: chathistory limit must be positive")
+)
+
+// ServerTimeFormat is the format used for timestamps by the IRCv3 server-time
+// extension and in chathistory selectors.
+const ServerTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// TimestampSelector builds a chathistory selector for the given time.
+func TimestampSelector(t time.Time) string {
+	return "timestamp=" + t.UTC().Format(ServerTimeFormat)
+}
+
+// MsgIDSelector builds a chathistory selector for the given msgid.
+func MsgIDSelector(msgid string) string {
+	return "msgid=" + msgid
+}
+
+// ChatHistoryLatest builds a CHATHISTORY LATEST message requesting the most
+// recent messages in target after the selector. The selector may also be * to
+// request the most recent messages without a bound.
+func ChatHistoryLatest(target, selector string, limit int) (*Message, error) {
+	if selector != "*" {
+		if err := validateChatHistorySelector(selector); err != nil {
+			return nil, err
+		}
+	}
+
+	return chatHistoryMessage("LATEST", target, []string{selector}, limit)
+}
+
+// ChatHistoryBefore builds a CHATHISTORY BEFORE message requesting messages in
+// target before the selector.
+func ChatHistoryBefore(target, selector string, limit int) (*Message, error) {
+	return chatHistorySelectors("BEFORE", target, []string{selector}, limit)
+}
+
+// ChatHistoryAfter builds a CHATHISTORY AFTER message requesting messages in
+// target after the selector.
+func ChatHistoryAfter(target, selector string, limit int) (*Message, error) {
+	return chatHistorySelectors("AFTER", target, []string{selector}, limit)
+}
+
+// ChatHistoryAround builds a CHATHISTORY AROUND message requesting messages in
+// target around the selector.
+func ChatHistoryAround(target, selector string, limit int) (*Message, error) {
+	return chatHistorySelectors("AROUND", target, []string{selector}, limit)
+}
+
+// ChatHistoryBetween builds a CHATHISTORY BETWEEN message requesting messages
+// in target between the two selectors.
+func ChatHistoryBetween(target, start, end string, limit int) (*Message, error) {
+	return chatHistorySelectors("BETWEEN", target, []string{start, end}, limit)
+}
+
+func chatHistorySelectors(subcommand, target string, selectors []string, limit int) (*Message, error) {
+	for _, selector := range selectors {
+		if err := validateChatHistorySelector(selector); err != nil {
+			return nil, err
+		}
+	}
+
+	return chatHistoryMessage(subcommand, target, selectors, limit)
+}
+
+func chatHistoryMessage(subcommand, target string, selectors []string, limit int) (*Message, error) {
+	if limit <= 0 {
+		return nil, ErrInvalidChatHistoryLimit
+	}
+
+	params := append([]string{subcommand, target}, selectors...)
+	params = append(params, strconv.Itoa(limit))
+
+	return &Message{
+		Command: "CHATHISTORY",
+		Params:  params,
+	}, nil
+}
+
+func validateChatHistorySelector(selector string) error {
+	parts := strings.SplitN(selector, "=", 2)
+	if len(parts) != 2 || parts[1] == "" || strings.ContainsRune(parts[1], ' ') {
+		return ErrInvalidChatHistorySelector
+	}
+
+	switch parts[0] {
+	case "timestamp":
+		if _, err := time.Parse(time.RFC3339Nano, parts[1]); err != nil {
+			return ErrInvalidChatHistorySelector
+		}
+	case "msgid":
+	default:
+		return ErrInvalidChatHistorySelector
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package irc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestChatHistory(t *testing.T) {
+	t.Parallel()
+
+	ts := irc.TimestampSelector(time.Date(2019, time.January, 4, 14, 33, 26, 123000000, time.UTC))
+	assert.Equal(t, "timestamp=2019-01-04T14:33:26.123Z", ts)
+	msgid := irc.MsgIDSelector("1234")
+	assert.Equal(t, "msgid=1234", msgid)
+
+	var testCases = []struct { //nolint:gofumpt
+		Build  func() (*irc.Message, error)
+		Expect string
+		Err    error
+	}{
+		{
+			Build:  func() (*irc.Message, error) { return irc.ChatHistoryLatest("#chan", "*", 100) },
+			Expect: "CHATHISTORY LATEST #chan * 100",
+		},
+		{
+			Build:  func() (*irc.Message, error) { return irc.ChatHistoryLatest("#chan", msgid, 10) },
+			Expect: "CHATHISTORY LATEST #chan msgid=1234 10",
+		},
+		{
+			Build:  func() (*irc.Message, error) { return irc.ChatHistoryBefore("#chan", ts, 50) },
+			Expect: "CHATHISTORY BEFORE #chan timestamp=2019-01-04T14:33:26.123Z 50",
+		},
+		{
+			Build:  func() (*irc.Message, error) { return irc.ChatHistoryAfter("nick", msgid, 50) },
+			Expect: "CHATHISTORY AFTER nick msgid=1234 50",
+		},
+		{
+			Build:  func() (*irc.Message, error) { return irc.ChatHistoryAround("#chan", msgid, 5) },
+			Expect: "CHATHISTORY AROUND #chan msgid=1234 5",
+		},
+		{
+			Build:  func() (*irc.Message, error) { return irc.ChatHistoryBetween("#chan", ts, msgid, 5) },
+			Expect: "CHATHISTORY BETWEEN #chan timestamp=2019-01-04T14:33:26.123Z msgid=1234 5",
+		},
+		{ // * is only valid for LATEST
+			Build: func() (*irc.Message, error) { return irc.ChatHistoryBefore("#chan", "*", 5) },
+			Err:   irc.ErrInvalidChatHistorySelector,
+		},
+		{
+			Build: func() (*irc.Message, error) { return irc.ChatHistoryBefore("#chan", "timestamp=yesterday", 5) },
+			Err:   irc.ErrInvalidChatHistorySelector,
+		},
+		{
+			Build: func() (*irc.Message, error) { return irc.ChatHistoryBefore("#chan", "msgid=", 5) },
+			Err:   irc.ErrInvalidChatHistorySelector,
+		},
+		{
+			Build: func() (*irc.Message, error) { return irc.ChatHistoryBetween("#chan", msgid, "1234", 5) },
+			Err:   irc.ErrInvalidChatHistorySelector,
+		},
+		{
+			Build: func() (*irc.Message, error) { return irc.ChatHistoryLatest("#chan", "*", 0) },
+			Err:   irc.ErrInvalidChatHistoryLimit,
+		},
+		{
+			Build: func() (*irc.Message, error) { return irc.ChatHistoryAfter("#chan", msgid, -1) },
+			Err:   irc.ErrInvalidChatHistoryLimit,
+		},
+	}
+
+	for i, testCase := range testCases {
+		m, err := testCase.Build()
+		assert.Equal(t, testCase.Err, err, "%d. Error didn't match expected", i)
+
+		if testCase.Err != nil {
+			assert.Nil(t, m, "%d. Didn't get nil message", i)
+		} else {
+			assert.Equal(t, testCase.Expect, m.String(), "%d. Message didn't match expected", i)
+		}
+	}
+}
@@ -191,6 +191,16 @@ func TestClient(t *testing.T) {
 		ExpectLine("PONG :hello world\r\n"),
 	})
 
+	// The built-in handler echoes every PING back unchanged, including ones
+	// with two params.
+	runClientTest(t, config, io.EOF, nil, []TestAction{
+		ExpectLine("PASS :test_pass\r\n"),
+		ExpectLine("NICK :test_nick\r\n"),
+		ExpectLine("USER test_user 0 * :test_name\r\n"),
+		SendLine("@a=b PING irc1.example.com :irc2.example.com\r\n"),
+		ExpectLine("@a=b PONG irc1.example.com irc2.example.com\r\n"),
+	})
+
 	c := runClientTest(t, config, io.EOF, nil, []TestAction{
 		ExpectLine("PASS :test_pass\r\n"),
 		ExpectLine("NICK :test_nick\r\n"),
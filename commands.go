@@ -0,0 +1,699 @@
+package irc
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// IsError returns true if this is an ERROR message. Servers send this right
+// before closing the connection, and the reason can be read with Trailing.
+func (m *Message) IsError() bool {
+	return m.Command == "ERROR"
+}
+
+// IsSetName returns true if this is a SETNAME message, sent when a user
+// changes their realname with the setname capability.
+func (m *Message) IsSetName() bool {
+	return m.Command == "SETNAME"
+}
+
+// NewRealName returns the new realname from a SETNAME message.
+func (m *Message) NewRealName() string {
+	return m.Trailing()
+}
+
+// IsPrivmsg returns true if this is a PRIVMSG message.
+func (m *Message) IsPrivmsg() bool {
+	return m.Command == "PRIVMSG"
+}
+
+// IsNotice returns true if this is a NOTICE message. Clients must never send
+// an automatic reply to a NOTICE, to prevent loops between bots, so these
+// should usually be handled separately from PRIVMSG messages.
+func (m *Message) IsNotice() bool {
+	return m.Command == "NOTICE"
+}
+
+// IsServerNotice returns true if this is a NOTICE sent by a server rather than
+// a user. A message without a prefix came from the server we're connected to,
+// so it is treated as a server notice as well.
+func (m *Message) IsServerNotice() bool {
+	if !m.IsNotice() {
+		return false
+	}
+
+	return m.Prefix == nil || *m.Prefix == Prefix{} || m.Prefix.IsServer()
+}
+
+// IsWallops returns true if this is a WALLOPS message, which is sent to all
+// users with the +w user mode, usually operators.
+func (m *Message) IsWallops() bool {
+	return m.Command == "WALLOPS"
+}
+
+// WallopsText returns the text of a WALLOPS message. It returns false if this
+// isn't a WALLOPS message.
+func (m *Message) WallopsText() (string, bool) {
+	if !m.IsWallops() || len(m.Params) < 1 {
+		return "", false
+	}
+
+	return m.Trailing(), true
+}
+
+// IsLabeledEcho returns true if this is the echo-message copy of a PRIVMSG,
+// NOTICE or TAGMSG we sent with the given label, so it can be matched up with
+// the message we already displayed. The message must have the label and come
+// from myNick, compared using mapping.
+//
+// If the server wraps the response in a labeled-response BATCH, the label is
+// only on the BATCH message, and the echo will have a batch tag instead. In
+// that case, the caller needs to remember which batch had the label and check
+// the echo against that instead.
+func (m *Message) IsLabeledEcho(label, myNick string, mapping CaseMapping) bool {
+	switch m.Command {
+	case "PRIVMSG", "NOTICE", "TAGMSG":
+	default:
+		return false
+	}
+
+	if got, ok := m.Label(); !ok || got != label {
+		return false
+	}
+
+	return m.IsFromNick(myNick, mapping)
+}
+
+// Notice builds a NOTICE message sending text to target.
+func Notice(target, text string) *Message {
+	return &Message{
+		Command: "NOTICE",
+		Params:  []string{target, text},
+	}
+}
+
+// IsAccountNotify returns true if this is an ACCOUNT message, sent with the
+// account-notify capability when a user logs in or out.
+func (m *Message) IsAccountNotify() bool {
+	return m.Command == "ACCOUNT" && len(m.Params) > 0
+}
+
+// AccountChange returns the new account from an ACCOUNT message. When a user
+// logs out, the server sends "*" as the account, so loggedIn will be false and
+// account will be empty. loggedIn will also be false if this isn't an ACCOUNT
+// message.
+func (m *Message) AccountChange() (account string, loggedIn bool) {
+	if !m.IsAccountNotify() || m.Params[0] == "*" {
+		return "", false
+	}
+
+	return m.Params[0], true
+}
+
+// Away builds an AWAY message marking us as away with the given reason. An
+// empty reason marks us as no longer away.
+func Away(reason string) *Message {
+	if reason == "" {
+		return &Message{Command: "AWAY"}
+	}
+
+	return &Message{
+		Command: "AWAY",
+		Params:  []string{reason},
+	}
+}
+
+// IsAway returns true if this is an AWAY message, sent for other users with
+// the away-notify capability.
+func (m *Message) IsAway() bool {
+	return m.Command == "AWAY"
+}
+
+// AwayReason returns the reason from an AWAY message. away will be false if
+// the user is no longer away or this isn't an AWAY message.
+func (m *Message) AwayReason() (reason string, away bool) {
+	if !m.IsAway() || m.Trailing() == "" {
+		return "", false
+	}
+
+	return m.Trailing(), true
+}
+
+// JoinInfo returns the params of an incoming JOIN message. With the
+// extended-join capability, the server also sends the account and realname of
+// the user joining, and extended will be true. The account will be empty if
+// the user isn't logged in, which the server sends as "*". If this isn't a
+// JOIN message, channel will be empty.
+func (m *Message) JoinInfo() (channel, account, realname string, extended bool) {
+	if m.Command != "JOIN" || len(m.Params) < 1 {
+		return "", "", "", false
+	}
+
+	if len(m.Params) < 3 {
+		return m.Params[0], "", "", false
+	}
+
+	account = m.Params[1]
+	if account == "*" {
+		account = ""
+	}
+
+	return m.Params[0], account, m.Params[2], true
+}
+
+// IsRename returns true if this is a RENAME message, sent when a channel is
+// renamed with the draft/channel-rename capability.
+func (m *Message) IsRename() bool {
+	return m.Command == "RENAME"
+}
+
+// RenameInfo returns the old and new channel names from a RENAME message. The
+// reason is optional, so it will be empty if the server didn't send one.
+func (m *Message) RenameInfo() (oldName, newName, reason string, ok bool) {
+	if !m.IsRename() || len(m.Params) < 2 {
+		return "", "", "", false
+	}
+
+	if len(m.Params) > 2 {
+		reason = m.Params[2]
+	}
+
+	return m.Params[0], m.Params[1], reason, true
+}
+
+// IsQuit returns true if this is a QUIT message.
+func (m *Message) IsQuit() bool {
+	return m.Command == "QUIT"
+}
+
+// QuitReason returns the reason from a QUIT message, or an empty string if
+// there isn't one or this isn't a QUIT message.
+func (m *Message) QuitReason() string {
+	if !m.IsQuit() {
+		return ""
+	}
+
+	return m.Trailing()
+}
+
+// IsNetsplitQuit checks if this is a QUIT caused by a netsplit, which servers
+// send with the names of the two servers which lost their link as the reason,
+// as in "hub.example.com leaf.example.com". Both names must look like
+// hostnames, so they need to contain a dot, but they may be masked with a '*',
+// as in "*.net *.split". User-supplied reasons can't be mistaken for this, as
+// servers prefix them with "Quit: ".
+func (m *Message) IsNetsplitQuit() (server1, server2 string, ok bool) {
+	parts := strings.Split(m.QuitReason(), " ")
+	if len(parts) != 2 || !looksLikeServer(parts[0]) || !looksLikeServer(parts[1]) {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+// looksLikeServer checks if name could be a server name: dot-separated labels
+// made up of letters, digits, '-' and '*', with at least one dot.
+func looksLikeServer(name string) bool {
+	if !strings.Contains(name, ".") {
+		return false
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		if label == "" {
+			return false
+		}
+
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != '-' && c != '*' {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// IsPart returns true if this is a PART message.
+func (m *Message) IsPart() bool {
+	return m.Command == "PART"
+}
+
+// PartInfo returns the channels and reason from a PART message. A single PART
+// may contain multiple channels separated by commas, so they are split up.
+// The reason is optional, so it will be empty if none was sent. channels will
+// be nil if this isn't a PART message.
+func (m *Message) PartInfo() (channels []string, reason string) {
+	if !m.IsPart() || len(m.Params) < 1 {
+		return nil, ""
+	}
+
+	if len(m.Params) > 1 {
+		reason = m.Params[1]
+	}
+
+	return strings.Split(m.Params[0], ","), reason
+}
+
+// IsNickChange returns true if this is a NICK message.
+func (m *Message) IsNickChange() bool {
+	return m.Command == "NICK"
+}
+
+// NickChange returns the old and new nick from a NICK message. The old nick
+// comes from the Prefix and the new nick from the first param, so ok will be
+// false if there is no prefix, as there is no way to tell who changed nick.
+func (m *Message) NickChange() (oldNick, newNick string, ok bool) {
+	if !m.IsNickChange() || len(m.Params) < 1 || m.Prefix == nil || m.Prefix.Name == "" {
+		return "", "", false
+	}
+
+	return m.Prefix.Name, m.Params[0], true
+}
+
+// Kick builds a KICK message removing nick from channel. If reason is empty,
+// it is left out and the server will use a default.
+func Kick(channel, nick, reason string) *Message {
+	m := &Message{
+		Command: "KICK",
+		Params:  []string{channel, nick},
+	}
+
+	if reason != "" {
+		m.Params = append(m.Params, reason)
+	}
+
+	return m
+}
+
+// KickInfo returns the params of a KICK message. The reason is optional, so
+// it will be empty if the server didn't send one. If multiple nicks were
+// kicked at once, nick will contain all of them separated by commas; use
+// KickTargets to split them up.
+func (m *Message) KickInfo() (channel, nick, reason string, ok bool) {
+	if m.Command != "KICK" || len(m.Params) < 2 {
+		return "", "", "", false
+	}
+
+	if len(m.Params) > 2 {
+		reason = m.Params[2]
+	}
+
+	return m.Params[0], m.Params[1], reason, true
+}
+
+// KickTargets returns all the nicks kicked by a KICK message, or nil if this
+// isn't a KICK message.
+func (m *Message) KickTargets() []string {
+	_, nicks, _, ok := m.KickInfo()
+	if !ok {
+		return nil
+	}
+
+	return strings.Split(nicks, ",")
+}
+
+// Invite builds an INVITE message inviting nick to channel.
+func Invite(nick, channel string) *Message {
+	return &Message{
+		Command: "INVITE",
+		Params:  []string{nick, channel},
+	}
+}
+
+// InviteInfo returns the invited nick and the channel from an INVITE message.
+// The user who sent the invite is in the Prefix. With the invite-notify
+// capability, nick may be someone other than us.
+func (m *Message) InviteInfo() (nick, channel string, ok bool) {
+	if m.Command != "INVITE" || len(m.Params) < 2 {
+		return "", "", false
+	}
+
+	return m.Params[0], m.Params[1], true
+}
+
+// Knock builds a KNOCK message asking the operators of channel for an invite.
+// If reason is empty, it is left out.
+func Knock(channel, reason string) *Message {
+	m := &Message{
+		Command: "KNOCK",
+		Params:  []string{channel},
+	}
+
+	if reason != "" {
+		m.Params = append(m.Params, reason)
+	}
+
+	return m
+}
+
+// IsKnock returns true if this is a KNOCK message.
+func (m *Message) IsKnock() bool {
+	return m.Command == "KNOCK"
+}
+
+// KnockInfo returns the channel and reason from a KNOCK message. The reason is
+// optional, so it will be empty if none was sent.
+func (m *Message) KnockInfo() (channel, reason string, ok bool) {
+	if !m.IsKnock() || len(m.Params) < 1 {
+		return "", "", false
+	}
+
+	if len(m.Params) > 1 {
+		reason = m.Params[1]
+	}
+
+	return m.Params[0], reason, true
+}
+
+// WebIRC builds a WEBIRC message, sent by a gateway before registration to
+// pass on the real host and IP of the user connecting through it. IPv6
+// addresses starting with a ':' are prefixed with a 0, as they can't be sent
+// as a middle param otherwise.
+func WebIRC(password, gateway, hostname, ip string) *Message {
+	if strings.HasPrefix(hostname, ":") {
+		hostname = "0" + hostname
+	}
+
+	if strings.HasPrefix(ip, ":") {
+		ip = "0" + ip
+	}
+
+	return &Message{
+		Command: "WEBIRC",
+		Params:  []string{password, gateway, hostname, ip},
+	}
+}
+
+// WebIRCInfo returns the params of a WEBIRC message, in the order they are
+// sent. Any options the gateway sent after the IP are ignored.
+func (m *Message) WebIRCInfo() (password, gateway, hostname, ip string, ok bool) {
+	if m.Command != "WEBIRC" || len(m.Params) < 4 {
+		return "", "", "", "", false
+	}
+
+	return m.Params[0], m.Params[1], m.Params[2], m.Params[3], true
+}
+
+// Register builds the messages needed to register a connection, in the order
+// they must be sent: PASS (omitted if pass is empty), NICK, then USER. CAP
+// negotiation is left to the caller, who should send CAP LS before these
+// messages if it is needed.
+func Register(nick, user, realname, pass string) []*Message {
+	ret := make([]*Message, 0, 3)
+
+	if pass != "" {
+		ret = append(ret, &Message{
+			Command: "PASS",
+			Params:  []string{pass},
+		})
+	}
+
+	return append(ret,
+		&Message{
+			Command: "NICK",
+			Params:  []string{nick},
+		},
+		&Message{
+			Command: "USER",
+			Params:  []string{user, "0", "*", realname},
+		},
+	)
+}
+
+// UserInfo returns the fields of a USER message, sent as "USER username mode
+// unused :realname". Modern servers treat mode as a bitmask of user modes to
+// set, but older clients send "USER username hostname servername :realname"
+// instead, in which case mode will be the hostname and should be ignored if it
+// isn't a number. It returns false if there are fewer than four params.
+func (m *Message) UserInfo() (username, mode, realname string, ok bool) {
+	if m.Command != "USER" || len(m.Params) < 4 {
+		return "", "", "", false
+	}
+
+	return m.Params[0], m.Params[1], m.Params[3], true
+}
+
+// Oper builds an OPER message, used to gain operator privileges. The message
+// contains the password in plain text, so make sure it isn't logged, such as
+// by a Writer's DebugCallback.
+func Oper(name, password string) *Message {
+	return &Message{
+		Command: "OPER",
+		Params:  []string{name, password},
+	}
+}
+
+// IsYoureOper returns true if this is an RPL_YOUREOPER message, sent when an
+// OPER command succeeds.
+func (m *Message) IsYoureOper() bool {
+	return m.Command == RPL_YOUREOPER
+}
+
+// IsOperError returns true if this is one of the errors sent when an OPER
+// command fails: ERR_NOOPERHOST (491) if we aren't connecting from an allowed
+// host, or ERR_PASSWDMISMATCH (464) if the password is wrong. Note that
+// ERR_PASSWDMISMATCH is also sent during registration for a bad server
+// password.
+func (m *Message) IsOperError() bool {
+	return m.Command == ERR_NOOPERHOST || m.Command == ERR_PASSWDMISMATCH
+}
+
+// IsSquit returns true if this is a SQUIT message, sent between servers when a
+// server leaves the network, or by an operator to disconnect a server.
+func (m *Message) IsSquit() bool {
+	return m.Command == "SQUIT"
+}
+
+// SquitInfo returns the server and reason from a SQUIT message. The reason is
+// optional, so it will be empty if none was sent.
+func (m *Message) SquitInfo() (server, reason string, ok bool) {
+	if !m.IsSquit() || len(m.Params) < 1 {
+		return "", "", false
+	}
+
+	if len(m.Params) > 1 {
+		reason = m.Params[1]
+	}
+
+	return m.Params[0], reason, true
+}
+
+// SplitPrivmsg builds the PRIVMSG messages needed to send text to target, with
+// each line (including the trailing CRLF) taking at most maxLen bytes. As the
+// server will add our prefix when relaying the message, callers should reduce
+// maxLen to leave room for it. If maxLen is zero or negative, MaxLineLength is
+// used.
+//
+// The text is split on newlines, then on spaces where possible, otherwise on
+// rune boundaries so multi-byte characters are never broken up. Empty lines
+// are skipped, so no messages are returned if there is no text.
+func SplitPrivmsg(target, text string, maxLen int) []*Message {
+	if maxLen <= 0 {
+		maxLen = MaxLineLength
+	}
+
+	budget := maxLen - len("PRIVMSG "+target+" :\r\n")
+
+	var ret []*Message
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		for _, chunk := range splitText(line, budget) {
+			ret = append(ret, &Message{
+				Command: "PRIVMSG",
+				Params:  []string{target, chunk},
+			})
+		}
+	}
+
+	return ret
+}
+
+// splitText splits text into chunks of at most budget bytes, preferring to
+// split on spaces. The space a chunk was split on is dropped. At least one rune
+// will always be put in each chunk, even if that goes over the budget.
+func splitText(text string, budget int) []string {
+	var ret []string
+
+	if budget < 1 {
+		budget = 1
+	}
+
+	for len(text) > budget {
+		// Find the last rune boundary which will fit in the budget.
+		cut := budget
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+
+		if cut == 0 {
+			_, cut = utf8.DecodeRuneInString(text)
+		} else if i := strings.LastIndexByte(text[:cut+1], ' '); i > 0 {
+			// If there's a space at or before the cut, we can split on
+			// it instead, dropping the space.
+			ret = append(ret, text[:i])
+			text = text[i+1:]
+			continue
+		}
+
+		ret = append(ret, text[:cut])
+		text = text[cut:]
+	}
+
+	if len(text) > 0 {
+		ret = append(ret, text)
+	}
+
+	return ret
+}
+
+// JoinMessage builds the JOIN messages needed to join all the given channels,
+// which map channel names to their keys. Channels without a key should map to
+// an empty string. Each line (including the trailing CRLF) will take at most
+// maxLen bytes. If maxLen is zero or negative, MaxLineLength is used.
+//
+// Keys are matched to channels by position, so channels with keys are always
+// put first. This means unkeyed channels never need an empty placeholder key.
+// Within each group, channels are sorted by name.
+//
+// This doesn't limit the number of channels in each message. Use
+// ISupport.JoinMessage to respect the server's TARGMAX.
+func JoinMessage(channels map[string]string, maxLen int) []*Message {
+	return joinMessage(channels, maxLen, 0)
+}
+
+// JoinMessage is the same as the JoinMessage function, but also puts no more
+// channels in each message than TARGMAX allows for JOIN.
+func (s ISupport) JoinMessage(channels map[string]string, maxLen int) []*Message {
+	maxTargets, _ := s.TargMax("JOIN")
+	return joinMessage(channels, maxLen, maxTargets)
+}
+
+// joinMessage implements JoinMessage. If maxTargets is zero, there is no limit
+// on the number of channels in each message.
+func joinMessage(channels map[string]string, maxLen, maxTargets int) []*Message {
+	if maxLen <= 0 {
+		maxLen = MaxLineLength
+	}
+
+	var keyed, unkeyed []string
+	for name, key := range channels {
+		if key != "" {
+			keyed = append(keyed, name)
+		} else {
+			unkeyed = append(unkeyed, name)
+		}
+	}
+
+	sort.Strings(keyed)
+	sort.Strings(unkeyed)
+
+	budget := maxLen - len("JOIN \r\n")
+
+	var ret []*Message
+	var names, keys []string
+	length := 0
+
+	flush := func() {
+		msg := &Message{
+			Command: "JOIN",
+			Params:  []string{strings.Join(names, ",")},
+		}
+		if len(keys) > 0 {
+			msg.Params = append(msg.Params, strings.Join(keys, ","))
+		}
+
+		ret = append(ret, msg)
+		names, keys = nil, nil
+		length = 0
+	}
+
+	// extra returns how much adding the given channel to the current message
+	// would add to its length.
+	extra := func(name, key string) int {
+		n := len(name)
+		if len(names) > 0 {
+			n++
+		}
+
+		// Each key needs either a comma or the space before the keys.
+		if key != "" {
+			n += 1 + len(key)
+		}
+
+		return n
+	}
+
+	for _, name := range append(keyed, unkeyed...) {
+		key := channels[name]
+
+		full := maxTargets > 0 && len(names) >= maxTargets
+		if len(names) > 0 && (full || length+extra(name, key) > budget) {
+			flush()
+		}
+
+		length += extra(name, key)
+		names = append(names, name)
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(names) > 0 {
+		flush()
+	}
+
+	return ret
+}
+
+// StandardReply is an IRCv3 standard reply, sent with a FAIL, WARN or NOTE
+// command. See https://ircv3.net/specs/extensions/standard-replies for more
+// information.
+type StandardReply struct {
+	// Severity is the command of the reply: FAIL, WARN or NOTE.
+	Severity string
+
+	// Command is the command this reply relates to, or * if it isn't related
+	// to a specific command.
+	Command string
+
+	// Code is a machine-readable code describing the reply.
+	Code string
+
+	// Context contains any additional params between the code and the
+	// description.
+	Context []string
+
+	// Description is the human-readable description of the reply.
+	Description string
+}
+
+// StandardReply parses an IRCv3 standard reply. It returns false if this is
+// not a FAIL, WARN or NOTE message or it is missing any required params.
+func (m *Message) StandardReply() (*StandardReply, bool) {
+	switch m.Command {
+	case "FAIL", "WARN", "NOTE":
+	default:
+		return nil, false
+	}
+
+	// We need at least a command, code and description.
+	if len(m.Params) < 3 {
+		return nil, false
+	}
+
+	ret := &StandardReply{
+		Severity:    m.Command,
+		Command:     m.Params[0],
+		Code:        m.Params[1],
+		Description: m.Trailing(),
+	}
+
+	if len(m.Params) > 3 {
+		ret.Context = append([]string(nil), m.Params[2:len(m.Params)-1]...)
+	}
+
+	return ret, true
+}
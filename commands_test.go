@@ -0,0 +1,816 @@
+package irc_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestMessageIsError(t *testing.T) {
+	t.Parallel()
+
+	m := irc.MustParseMessage("ERROR :Closing Link: host (Quit: bye)")
+	assert.True(t, m.IsError())
+	assert.Equal(t, "Closing Link: host (Quit: bye)", m.Trailing())
+
+	m = irc.MustParseMessage("QUIT :Closing Link")
+	assert.False(t, m.IsError())
+}
+
+func TestMessageSetName(t *testing.T) {
+	t.Parallel()
+
+	m := &irc.Message{
+		Prefix:  irc.ParsePrefix("nick!user@host"),
+		Command: "SETNAME",
+		Params:  []string{"Lemuria of the Philippines"},
+	}
+	assert.Equal(t, ":nick!user@host SETNAME :Lemuria of the Philippines", m.String())
+
+	m = irc.MustParseMessage(m.String())
+	assert.True(t, m.IsSetName())
+	assert.Equal(t, "Lemuria of the Philippines", m.NewRealName())
+
+	assert.False(t, irc.MustParseMessage("NICK :new").IsSetName())
+}
+
+func TestMessageIsPrivmsgNotice(t *testing.T) {
+	t.Parallel()
+
+	m := irc.MustParseMessage(":nick!user@host NOTICE #chan :hello")
+	assert.True(t, m.IsNotice())
+	assert.False(t, m.IsPrivmsg())
+
+	m = irc.MustParseMessage(":nick!user@host PRIVMSG #chan :hello")
+	assert.True(t, m.IsPrivmsg())
+	assert.False(t, m.IsNotice())
+
+	m = irc.Notice("#chan", "hello world")
+	assert.True(t, m.IsNotice())
+	assert.False(t, m.IsPrivmsg())
+	assert.Equal(t, "NOTICE #chan :hello world", m.String())
+}
+
+func TestMessageIsServerNotice(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, irc.MustParseMessage(":irc.example.com NOTICE * :*** Looking up your hostname").IsServerNotice())
+	assert.True(t, irc.MustParseMessage("NOTICE AUTH :*** Looking up your hostname").IsServerNotice())
+	assert.False(t, irc.MustParseMessage(":nick!user@host NOTICE me :hello").IsServerNotice())
+	assert.False(t, irc.MustParseMessage(":nick NOTICE me :hello").IsServerNotice())
+	assert.False(t, irc.MustParseMessage(":irc.example.com PRIVMSG me :hello").IsServerNotice())
+}
+
+func TestMessageWallops(t *testing.T) {
+	t.Parallel()
+
+	m := irc.MustParseMessage(":oper!user@host WALLOPS :Server restarting soon")
+	assert.True(t, m.IsWallops())
+	text, ok := m.WallopsText()
+	assert.True(t, ok)
+	assert.Equal(t, "Server restarting soon", text)
+
+	m = irc.MustParseMessage(":oper!user@host PRIVMSG #chan :Server restarting soon")
+	assert.False(t, m.IsWallops())
+	_, ok = m.WallopsText()
+	assert.False(t, ok)
+}
+
+func TestMessageAccountChange(t *testing.T) {
+	t.Parallel()
+
+	m := irc.MustParseMessage(":nick!user@host ACCOUNT lemuria")
+	assert.True(t, m.IsAccountNotify())
+	account, loggedIn := m.AccountChange()
+	assert.True(t, loggedIn)
+	assert.Equal(t, "lemuria", account)
+
+	m = irc.MustParseMessage(":nick!user@host ACCOUNT *")
+	assert.True(t, m.IsAccountNotify())
+	account, loggedIn = m.AccountChange()
+	assert.False(t, loggedIn)
+	assert.Equal(t, "", account)
+
+	m = irc.MustParseMessage(":nick!user@host ACCOUNT")
+	assert.False(t, m.IsAccountNotify())
+
+	m = irc.MustParseMessage("@account=lemuria :nick!user@host PRIVMSG #chan :hello")
+	assert.False(t, m.IsAccountNotify())
+	_, loggedIn = m.AccountChange()
+	assert.False(t, loggedIn)
+}
+
+func TestMessageIsLabeledEcho(t *testing.T) {
+	t.Parallel()
+
+	sent := irc.Message{
+		Tags:    irc.Tags{"label": "abc"},
+		Command: "PRIVMSG",
+		Params:  []string{"#chan", "hello"},
+	}
+	assert.Equal(t, "@label=abc PRIVMSG #chan hello", sent.String())
+
+	m := irc.MustParseMessage("@label=abc;msgid=123 :Lemuria!user@host PRIVMSG #chan hello")
+	assert.True(t, m.IsLabeledEcho("abc", "lemuria", irc.CaseMappingRFC1459))
+
+	// Wrong label
+	assert.False(t, m.IsLabeledEcho("def", "lemuria", irc.CaseMappingRFC1459))
+
+	// Someone else
+	assert.False(t, m.IsLabeledEcho("abc", "other", irc.CaseMappingRFC1459))
+
+	// No label at all
+	m = irc.MustParseMessage(":lemuria!user@host PRIVMSG #chan hello")
+	assert.False(t, m.IsLabeledEcho("abc", "lemuria", irc.CaseMappingRFC1459))
+	_, ok := m.Label()
+	assert.False(t, ok)
+
+	// Other labeled responses aren't echoes
+	m = irc.MustParseMessage("@label=abc :irc.example.com 401 lemuria nobody :No such nick")
+	assert.False(t, m.IsLabeledEcho("abc", "lemuria", irc.CaseMappingRFC1459))
+	label, ok := m.Label()
+	assert.True(t, ok)
+	assert.Equal(t, "abc", label)
+}
+
+func TestAway(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "AWAY :gone to lunch", irc.Away("gone to lunch").String())
+	assert.Equal(t, "AWAY", irc.Away("").String())
+
+	m := irc.MustParseMessage(":nick!user@host AWAY :gone to lunch")
+	assert.True(t, m.IsAway())
+	reason, away := m.AwayReason()
+	assert.True(t, away)
+	assert.Equal(t, "gone to lunch", reason)
+
+	m = irc.MustParseMessage(":nick!user@host AWAY")
+	assert.True(t, m.IsAway())
+	reason, away = m.AwayReason()
+	assert.False(t, away)
+	assert.Equal(t, "", reason)
+
+	m = irc.MustParseMessage(":nick!user@host PRIVMSG #chan :away")
+	assert.False(t, m.IsAway())
+	_, away = m.AwayReason()
+	assert.False(t, away)
+}
+
+func TestMessageJoinInfo(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input    string
+		Channel  string
+		Account  string
+		Realname string
+		Extended bool
+	}{
+		{
+			Input:   ":nick!user@host JOIN #chan",
+			Channel: "#chan",
+		},
+		{
+			Input:    ":nick!user@host JOIN #chan account :Real Name",
+			Channel:  "#chan",
+			Account:  "account",
+			Realname: "Real Name",
+			Extended: true,
+		},
+		{
+			Input:    ":nick!user@host JOIN #chan * :Real Name",
+			Channel:  "#chan",
+			Realname: "Real Name",
+			Extended: true,
+		},
+		{
+			Input: ":nick!user@host PART #chan",
+		},
+	}
+
+	for _, testCase := range testCases {
+		channel, account, realname, extended := irc.MustParseMessage(testCase.Input).JoinInfo()
+		assert.Equal(t, testCase.Channel, channel, "channel didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Account, account, "account didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Realname, realname, "realname didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Extended, extended, "extended didn't match for %q", testCase.Input)
+	}
+}
+
+func TestMessageRenameInfo(t *testing.T) {
+	t.Parallel()
+
+	m := irc.MustParseMessage(":irc.example.com RENAME #old #new :Moving to a better name")
+	assert.True(t, m.IsRename())
+	oldName, newName, reason, ok := m.RenameInfo()
+	assert.True(t, ok)
+	assert.Equal(t, "#old", oldName)
+	assert.Equal(t, "#new", newName)
+	assert.Equal(t, "Moving to a better name", reason)
+
+	m = irc.MustParseMessage(":irc.example.com RENAME #old #new")
+	assert.True(t, m.IsRename())
+	oldName, newName, reason, ok = m.RenameInfo()
+	assert.True(t, ok)
+	assert.Equal(t, "#old", oldName)
+	assert.Equal(t, "#new", newName)
+	assert.Equal(t, "", reason)
+
+	_, _, _, ok = irc.MustParseMessage(":irc.example.com RENAME #old").RenameInfo()
+	assert.False(t, ok)
+
+	m = irc.MustParseMessage(":nick!user@host NICK new")
+	assert.False(t, m.IsRename())
+	_, _, _, ok = m.RenameInfo()
+	assert.False(t, ok)
+}
+
+func TestMessageQuitReason(t *testing.T) {
+	t.Parallel()
+
+	m := irc.MustParseMessage(":nick!user@host QUIT :Ping timeout: 120 seconds")
+	assert.True(t, m.IsQuit())
+	assert.Equal(t, "Ping timeout: 120 seconds", m.QuitReason())
+
+	m = irc.MustParseMessage(":nick!user@host QUIT")
+	assert.True(t, m.IsQuit())
+	assert.Equal(t, "", m.QuitReason())
+
+	m = irc.MustParseMessage(":nick!user@host PART #chan :bye")
+	assert.False(t, m.IsQuit())
+	assert.Equal(t, "", m.QuitReason())
+}
+
+func TestMessageIsNetsplitQuit(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input   string
+		Server1 string
+		Server2 string
+		OK      bool
+	}{
+		{
+			Input:   ":nick!user@host QUIT :hub.example.com leaf.example.com",
+			Server1: "hub.example.com",
+			Server2: "leaf.example.com",
+			OK:      true,
+		},
+		{
+			Input:   ":nick!user@host QUIT :*.net *.split",
+			Server1: "*.net",
+			Server2: "*.split",
+			OK:      true,
+		},
+		{Input: ":nick!user@host QUIT :Quit: hub.example.com leaf.example.com"},
+		{Input: ":nick!user@host QUIT :Ping timeout: 120 seconds"},
+		{Input: ":nick!user@host QUIT :see you later"},
+		{Input: ":nick!user@host QUIT :hub.example.com"},
+		{Input: ":nick!user@host QUIT :hub.example.com  leaf.example.com"},
+		{Input: ":nick!user@host QUIT :hub..example.com leaf.example.com"},
+		{Input: ":nick!user@host QUIT :example.com. leaf.example.com"},
+		{Input: ":nick!user@host QUIT :bye! leaf.example.com"},
+		{Input: ":nick!user@host QUIT"},
+		{Input: ":nick!user@host PART #chan :hub.example.com leaf.example.com"},
+	}
+
+	for _, testCase := range testCases {
+		server1, server2, ok := irc.MustParseMessage(testCase.Input).IsNetsplitQuit()
+		assert.Equal(t, testCase.OK, ok, "OK didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Server1, server1, "Server1 didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Server2, server2, "Server2 didn't match for %q", testCase.Input)
+	}
+}
+
+func TestMessagePartInfo(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input    string
+		Channels []string
+		Reason   string
+	}{
+		{
+			Input:    ":nick!user@host PART #chan",
+			Channels: []string{"#chan"},
+		},
+		{
+			Input:    ":nick!user@host PART #a,#b,#c",
+			Channels: []string{"#a", "#b", "#c"},
+		},
+		{
+			Input:    ":nick!user@host PART #a,#b :see you later",
+			Channels: []string{"#a", "#b"},
+			Reason:   "see you later",
+		},
+		{
+			Input: ":nick!user@host PART",
+		},
+		{
+			Input: ":nick!user@host QUIT :bye",
+		},
+	}
+
+	for _, testCase := range testCases {
+		m := irc.MustParseMessage(testCase.Input)
+		channels, reason := m.PartInfo()
+		assert.Equal(t, testCase.Channels, channels, "channels didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Reason, reason, "reason didn't match for %q", testCase.Input)
+	}
+
+	assert.True(t, irc.MustParseMessage(":nick!user@host PART #chan").IsPart())
+}
+
+func TestMessageNickChange(t *testing.T) {
+	t.Parallel()
+
+	m := irc.MustParseMessage(":old!user@host NICK :new")
+	assert.True(t, m.IsNickChange())
+	oldNick, newNick, ok := m.NickChange()
+	assert.True(t, ok)
+	assert.Equal(t, "old", oldNick)
+	assert.Equal(t, "new", newNick)
+
+	// Without a prefix, there's no way to know who changed nick
+	m = irc.MustParseMessage("NICK new")
+	assert.True(t, m.IsNickChange())
+	_, _, ok = m.NickChange()
+	assert.False(t, ok)
+
+	m.Prefix = nil
+	_, _, ok = m.NickChange()
+	assert.False(t, ok)
+
+	_, _, ok = irc.MustParseMessage(":old!user@host NICK").NickChange()
+	assert.False(t, ok)
+
+	m = irc.MustParseMessage(":old!user@host PRIVMSG new :hi")
+	assert.False(t, m.IsNickChange())
+	_, _, ok = m.NickChange()
+	assert.False(t, ok)
+}
+
+func TestKick(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "KICK #chan nick :be nice", irc.Kick("#chan", "nick", "be nice").String())
+	assert.Equal(t, "KICK #chan nick", irc.Kick("#chan", "nick", "").String())
+
+	var testCases = []struct { //nolint:gofumpt
+		Input   string
+		Channel string
+		Nick    string
+		Reason  string
+		Targets []string
+		OK      bool
+	}{
+		{
+			Input:   ":op!user@host KICK #chan nick :be nice",
+			Channel: "#chan",
+			Nick:    "nick",
+			Reason:  "be nice",
+			Targets: []string{"nick"},
+			OK:      true,
+		},
+		{
+			Input:   ":op!user@host KICK #chan nick",
+			Channel: "#chan",
+			Nick:    "nick",
+			Targets: []string{"nick"},
+			OK:      true,
+		},
+		{
+			Input:   ":op!user@host KICK #chan nick1,nick2 :spam",
+			Channel: "#chan",
+			Nick:    "nick1,nick2",
+			Reason:  "spam",
+			Targets: []string{"nick1", "nick2"},
+			OK:      true,
+		},
+		{
+			Input:   ":op!user@host KICK #chan nick1,nick2",
+			Channel: "#chan",
+			Nick:    "nick1,nick2",
+			Targets: []string{"nick1", "nick2"},
+			OK:      true,
+		},
+		{
+			Input: ":op!user@host KICK #chan",
+		},
+		{
+			Input: ":op!user@host PART #chan nick",
+		},
+	}
+
+	for _, testCase := range testCases {
+		m := irc.MustParseMessage(testCase.Input)
+
+		channel, nick, reason, ok := m.KickInfo()
+		assert.Equal(t, testCase.OK, ok, "ok didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Channel, channel, "channel didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Nick, nick, "nick didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Reason, reason, "reason didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Targets, m.KickTargets(), "targets didn't match for %q", testCase.Input)
+	}
+}
+
+func TestInvite(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "INVITE nick #chan", irc.Invite("nick", "#chan").String())
+
+	m := irc.MustParseMessage(":op!user@host INVITE lemuria :#lemuria")
+	nick, channel, ok := m.InviteInfo()
+	assert.True(t, ok)
+	assert.Equal(t, "lemuria", nick)
+	assert.Equal(t, "#lemuria", channel)
+	assert.Equal(t, "op", m.Prefix.Name)
+
+	_, _, ok = irc.MustParseMessage(":op!user@host INVITE lemuria").InviteInfo()
+	assert.False(t, ok)
+
+	_, _, ok = irc.MustParseMessage(":op!user@host KICK #lemuria lemuria").InviteInfo()
+	assert.False(t, ok)
+}
+
+func TestKnock(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "KNOCK #chan :let me in", irc.Knock("#chan", "let me in").String())
+	assert.Equal(t, "KNOCK #chan", irc.Knock("#chan", "").String())
+
+	m := irc.MustParseMessage(":nick!user@host KNOCK #chan :let me in")
+	assert.True(t, m.IsKnock())
+	channel, reason, ok := m.KnockInfo()
+	assert.True(t, ok)
+	assert.Equal(t, "#chan", channel)
+	assert.Equal(t, "let me in", reason)
+
+	m = irc.MustParseMessage(":nick!user@host KNOCK #chan")
+	channel, reason, ok = m.KnockInfo()
+	assert.True(t, ok)
+	assert.Equal(t, "#chan", channel)
+	assert.Equal(t, "", reason)
+
+	_, _, ok = irc.MustParseMessage(":nick!user@host KNOCK").KnockInfo()
+	assert.False(t, ok)
+
+	m = irc.MustParseMessage(":nick!user@host INVITE lemuria #chan")
+	assert.False(t, m.IsKnock())
+	_, _, ok = m.KnockInfo()
+	assert.False(t, ok)
+}
+
+func TestWebIRC(t *testing.T) {
+	t.Parallel()
+
+	m := irc.WebIRC("hunter2", "gateway", "user.example.com", "192.0.2.1")
+	assert.Equal(t, "WEBIRC hunter2 gateway user.example.com 192.0.2.1", m.String())
+
+	m = irc.WebIRC("hunter2", "gateway", "::1", "::1")
+	assert.Equal(t, "WEBIRC hunter2 gateway 0::1 0::1", m.String())
+
+	m = irc.MustParseMessage("WEBIRC hunter2 gateway user.example.com 2001:db8::1 :secure")
+	password, gateway, hostname, ip, ok := m.WebIRCInfo()
+	assert.True(t, ok)
+	assert.Equal(t, "hunter2", password)
+	assert.Equal(t, "gateway", gateway)
+	assert.Equal(t, "user.example.com", hostname)
+	assert.Equal(t, "2001:db8::1", ip)
+
+	_, _, _, _, ok = irc.MustParseMessage("WEBIRC hunter2 gateway user.example.com").WebIRCInfo()
+	assert.False(t, ok)
+
+	_, _, _, _, ok = irc.MustParseMessage("USER a b c d").WebIRCInfo()
+	assert.False(t, ok)
+}
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	var lines []string
+	for _, m := range irc.Register("lemuria", "lemur", "Lemuria Lemur", "hunter2") {
+		lines = append(lines, m.String())
+	}
+	assert.Equal(t, []string{
+		"PASS hunter2",
+		"NICK lemuria",
+		"USER lemur 0 * :Lemuria Lemur",
+	}, lines)
+
+	msgs := irc.Register("lemuria", "lemur", "lemuria", "")
+	if !assert.Len(t, msgs, 2) {
+		return
+	}
+	assert.Equal(t, "NICK", msgs[0].Command)
+	assert.Equal(t, []string{"lemuria"}, msgs[0].Params)
+	assert.Equal(t, "USER", msgs[1].Command)
+	assert.Equal(t, []string{"lemur", "0", "*", "lemuria"}, msgs[1].Params)
+}
+
+func TestUserInfo(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input    string
+		Username string
+		Mode     string
+		Realname string
+		OK       bool
+	}{
+		{
+			Input:    "USER lemur 0 * :Lemuria Lemur",
+			Username: "lemur",
+			Mode:     "0",
+			Realname: "Lemuria Lemur",
+			OK:       true,
+		},
+		{
+			Input:    "USER lemur 8 * :Lemuria Lemur",
+			Username: "lemur",
+			Mode:     "8",
+			Realname: "Lemuria Lemur",
+			OK:       true,
+		},
+		{
+			// Legacy layout, with a hostname and servername
+			Input:    "USER lemur lemuria.ph irc.example.com :Lemuria Lemur",
+			Username: "lemur",
+			Mode:     "lemuria.ph",
+			Realname: "Lemuria Lemur",
+			OK:       true,
+		},
+		{
+			Input: "USER lemur 0 *",
+		},
+		{
+			Input: "NICK lemur 0 * :Lemuria Lemur",
+		},
+	}
+
+	for _, testCase := range testCases {
+		username, mode, realname, ok := irc.MustParseMessage(testCase.Input).UserInfo()
+		assert.Equal(t, testCase.OK, ok, "OK didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Username, username, "Username didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Mode, mode, "Mode didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Realname, realname, "Realname didn't match for %q", testCase.Input)
+	}
+
+	// Round trip with Register
+	msgs := irc.Register("lemuria", "lemur", "Lemuria Lemur", "")
+	username, _, realname, ok := msgs[len(msgs)-1].UserInfo()
+	assert.True(t, ok)
+	assert.Equal(t, "lemur", username)
+	assert.Equal(t, "Lemuria Lemur", realname)
+}
+
+func TestOper(t *testing.T) {
+	t.Parallel()
+
+	m := irc.Oper("lemuria", "hunter2 with spaces")
+	assert.Equal(t, "OPER", m.Command)
+	assert.Equal(t, []string{"lemuria", "hunter2 with spaces"}, m.Params)
+	assert.Equal(t, "OPER lemuria :hunter2 with spaces", m.String())
+
+	m = irc.MustParseMessage(":irc.example.com 381 me :You are now an IRC operator")
+	assert.True(t, m.IsYoureOper())
+	assert.False(t, m.IsOperError())
+
+	for _, line := range []string{
+		":irc.example.com 491 me :No O-lines for your host",
+		":irc.example.com 464 me :Password incorrect",
+	} {
+		m = irc.MustParseMessage(line)
+		assert.False(t, m.IsYoureOper(), "IsYoureOper didn't match for %q", line)
+		assert.True(t, m.IsOperError(), "IsOperError didn't match for %q", line)
+	}
+
+	m = irc.MustParseMessage(":irc.example.com 001 me :Welcome")
+	assert.False(t, m.IsYoureOper())
+	assert.False(t, m.IsOperError())
+}
+
+func TestSquit(t *testing.T) {
+	t.Parallel()
+
+	m := irc.MustParseMessage(":hub.example.com SQUIT leaf.example.com :Ping timeout")
+	assert.True(t, m.IsSquit())
+	server, reason, ok := m.SquitInfo()
+	assert.True(t, ok)
+	assert.Equal(t, "leaf.example.com", server)
+	assert.Equal(t, "Ping timeout", reason)
+
+	server, reason, ok = irc.MustParseMessage("SQUIT leaf.example.com").SquitInfo()
+	assert.True(t, ok)
+	assert.Equal(t, "leaf.example.com", server)
+	assert.Equal(t, "", reason)
+
+	_, _, ok = irc.MustParseMessage("SQUIT").SquitInfo()
+	assert.False(t, ok)
+
+	m = irc.MustParseMessage(":nick!user@host QUIT :hub.example.com leaf.example.com")
+	assert.False(t, m.IsSquit())
+	_, _, ok = m.SquitInfo()
+	assert.False(t, ok)
+}
+
+func TestSplitPrivmsg(t *testing.T) {
+	t.Parallel()
+
+	// "PRIVMSG #c :" and the CRLF take up 14 bytes, leaving 10 for text.
+	budget := 24
+
+	var testCases = []struct { //nolint:gofumpt
+		Text   string
+		Expect []string
+	}{
+		{
+			Text:   "",
+			Expect: nil,
+		},
+		{
+			Text:   "short",
+			Expect: []string{"short"},
+		},
+		{ // Exactly at the budget
+			Text:   "0123456789",
+			Expect: []string{"0123456789"},
+		},
+		{ // Split on word boundaries
+			Text:   "the quick brown fox jumps",
+			Expect: []string{"the quick", "brown fox", "jumps"},
+		},
+		{ // A space right at the budget
+			Text:   "0123456789 next",
+			Expect: []string{"0123456789", "next"},
+		},
+		{ // A word longer than the budget
+			Text:   "a supercalifragilistic word",
+			Expect: []string{"a", "supercalif", "ragilistic", "word"},
+		},
+		{ // Multi-byte runes should never be split. Each of these is 3 bytes.
+			Text:   "日本語のテキスト",
+			Expect: []string{"日本語", "のテキ", "スト"},
+		},
+		{ // Newlines always split
+			Text:   "one\r\ntwo\n\nthree",
+			Expect: []string{"one", "two", "three"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		msgs := irc.SplitPrivmsg("#c", testCase.Text, budget)
+
+		var lines []string
+		for _, m := range msgs {
+			assert.Equal(t, "PRIVMSG", m.Command)
+			assert.Equal(t, "#c", m.Param(0))
+			assert.True(t, utf8.ValidString(m.Trailing()), "Invalid UTF-8 for %q", testCase.Text)
+			lines = append(lines, m.Trailing())
+		}
+
+		assert.Equal(t, testCase.Expect, lines, "Lines didn't match for %q", testCase.Text)
+	}
+
+	// Make sure lines fit when using the default.
+	for _, m := range irc.SplitPrivmsg("#chan", strings.Repeat("wörd ", 500), 0) {
+		assert.True(t, len(m.String()+"\r\n") <= irc.MaxLineLength)
+	}
+
+	// Even with no room, we need to make progress.
+	msgs := irc.SplitPrivmsg("#c", "ab", 1)
+	assert.Len(t, msgs, 2)
+}
+
+func TestJoinMessage(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, irc.JoinMessage(nil, 0))
+
+	channels := map[string]string{
+		"#c": "key_c",
+		"#b": "",
+		"#a": "key_a",
+		"#d": "",
+	}
+
+	msgs := irc.JoinMessage(channels, 0)
+	if assert.Len(t, msgs, 1) {
+		assert.Equal(t, "JOIN #a,#c,#b,#d key_a,key_c", msgs[0].String())
+	}
+
+	// Force the channels to be split between messages. Each line must fit
+	// in the limit, including the CRLF.
+	msgs = irc.JoinMessage(channels, len("JOIN #a,#c key_a,key_c\r\n"))
+	var lines []string
+	for _, msg := range msgs {
+		lines = append(lines, msg.String())
+	}
+	assert.Equal(t, []string{
+		"JOIN #a,#c key_a,key_c",
+		"JOIN #b,#d",
+	}, lines)
+
+	// A single channel which doesn't fit should still be sent.
+	msgs = irc.JoinMessage(map[string]string{"#long": "secret"}, 10)
+	if assert.Len(t, msgs, 1) {
+		assert.Equal(t, []string{"#long", "secret"}, msgs[0].Params)
+	}
+}
+
+func TestISupportJoinMessage(t *testing.T) {
+	t.Parallel()
+
+	channels := map[string]string{
+		"#c": "key_c",
+		"#b": "",
+		"#a": "key_a",
+		"#d": "",
+		"#e": "",
+	}
+
+	joinLines := func(msgs []*irc.Message) []string {
+		var lines []string
+		for _, msg := range msgs {
+			lines = append(lines, msg.String())
+		}
+		return lines
+	}
+
+	supports := irc.ISupport{"TARGMAX": "PRIVMSG:4,JOIN:2,KICK:1"}
+	assert.Equal(t, []string{
+		"JOIN #a,#c key_a,key_c",
+		"JOIN #b,#d",
+		"JOIN #e",
+	}, joinLines(supports.JoinMessage(channels, 0)))
+
+	// The line length still applies.
+	assert.Equal(t, []string{
+		"JOIN #a key_a",
+		"JOIN #c key_c",
+		"JOIN #b,#d",
+		"JOIN #e",
+	}, joinLines(supports.JoinMessage(channels, len("JOIN #b,#d\r\n"))))
+
+	// Without a limit for JOIN, this is the same as JoinMessage.
+	for _, supports := range []irc.ISupport{nil, {"TARGMAX": "PRIVMSG:4,JOIN:"}} {
+		assert.Equal(t, joinLines(irc.JoinMessage(channels, 0)), joinLines(supports.JoinMessage(channels, 0)))
+	}
+}
+
+func TestMessageStandardReply(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input  string
+		Expect *irc.StandardReply
+	}{
+		{
+			Input: "FAIL * NEED_REGISTRATION :You need to be registered to continue",
+			Expect: &irc.StandardReply{
+				Severity:    "FAIL",
+				Command:     "*",
+				Code:        "NEED_REGISTRATION",
+				Description: "You need to be registered to continue",
+			},
+		},
+		{
+			Input: "WARN REHASH CERTS_EXPIRED cert1 cert2 :Certificates have expired",
+			Expect: &irc.StandardReply{
+				Severity:    "WARN",
+				Command:     "REHASH",
+				Code:        "CERTS_EXPIRED",
+				Context:     []string{"cert1", "cert2"},
+				Description: "Certificates have expired",
+			},
+		},
+		{
+			Input: "NOTE * OPER_MESSAGE :The message",
+			Expect: &irc.StandardReply{
+				Severity:    "NOTE",
+				Command:     "*",
+				Code:        "OPER_MESSAGE",
+				Description: "The message",
+			},
+		},
+		{
+			Input:  "FAIL * :Missing code",
+			Expect: nil,
+		},
+		{
+			Input:  "PRIVMSG #chan CODE :Not a reply",
+			Expect: nil,
+		},
+	}
+
+	for _, testCase := range testCases {
+		reply, ok := irc.MustParseMessage(testCase.Input).StandardReply()
+		assert.Equal(t, testCase.Expect != nil, ok, "ok didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Expect, reply, "Reply didn't match for %q", testCase.Input)
+	}
+}
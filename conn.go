@@ -2,9 +2,11 @@ package irc
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"time"
 )
 
 // Conn represents a simple IRC client. It embeds an irc.Reader and an
@@ -33,6 +35,12 @@ type Writer struct {
 	// stability guarantee.
 	WriteCallback func(w *Writer, line string) error
 
+	// AddServerTime adds a time tag with the current time to messages sent
+	// with WriteMessage if they don't already have one. This is useful when
+	// relaying messages which may be stored and replayed later. The message
+	// passed in is never modified.
+	AddServerTime bool
+
 	// Internal fields
 	writer io.Writer
 }
@@ -44,7 +52,10 @@ func defaultWriteCallback(w *Writer, line string) error {
 
 // NewWriter creates an irc.Writer from an io.Writer.
 func NewWriter(w io.Writer) *Writer {
-	return &Writer{nil, defaultWriteCallback, w}
+	return &Writer{
+		WriteCallback: defaultWriteCallback,
+		writer:        w,
+	}
 }
 
 // RawWrite will write the given data to the underlying connection, skipping the
@@ -75,9 +86,110 @@ func (w *Writer) Writef(format string, args ...interface{}) error {
 
 // WriteMessage writes the given message to the stream.
 func (w *Writer) WriteMessage(m *Message) error {
+	if w.AddServerTime && !m.Tags.IsSet("time") {
+		m = m.Clone()
+		m.Tags = m.SnapshotTags()
+		m.Tags["time"] = time.Now().UTC().Format(ServerTimeFormat)
+	}
+
 	return w.Write(m.String())
 }
 
+// DefaultBufferedWriterSize is the buffer size used by NewBufferedWriter if
+// none is given.
+const DefaultBufferedWriterSize = 4096
+
+// BufferedWriter is a Writer which buffers outgoing lines, so a burst of
+// messages (such as joining many channels) can be sent with fewer writes to
+// the underlying connection.
+//
+// Buffered lines are written when Flush is called, or when the next line
+// would not fit in the buffer. In the second case, only the complete lines
+// already buffered are written, so a line is never split between two writes
+// unless it is larger than the buffer by itself, in which case it is written
+// directly. Nothing is sent until one of these happens, so Flush must be
+// called after each burst of messages.
+type BufferedWriter struct {
+	*Writer
+
+	buf *bufio.Writer
+}
+
+// lineBuffer is a bufio.Writer which flushes before a write rather than in the
+// middle of one.
+type lineBuffer struct {
+	*bufio.Writer
+}
+
+func (b lineBuffer) Write(data []byte) (int, error) {
+	if len(data) > b.Available() && b.Buffered() > 0 {
+		if err := b.Flush(); err != nil {
+			return 0, err
+		}
+	}
+
+	return b.Writer.Write(data)
+}
+
+// NewBufferedWriter creates an irc.BufferedWriter from an io.Writer, which
+// will buffer up to size bytes. If size is zero or negative,
+// DefaultBufferedWriterSize is used.
+func NewBufferedWriter(w io.Writer, size int) *BufferedWriter {
+	if size <= 0 {
+		size = DefaultBufferedWriterSize
+	}
+
+	buf := bufio.NewWriterSize(w, size)
+
+	return &BufferedWriter{
+		Writer: NewWriter(lineBuffer{buf}),
+		buf:    buf,
+	}
+}
+
+// Flush writes any buffered lines to the underlying io.Writer.
+func (w *BufferedWriter) Flush() error {
+	return w.buf.Flush()
+}
+
+// Buffered returns the number of bytes waiting to be written by Flush.
+func (w *BufferedWriter) Buffered() int {
+	return w.buf.Buffered()
+}
+
+// ErrLineTooLong is returned when reading a line longer than
+// Reader.MaxLineLength. The error will be a *LineTooLongError, which holds the
+// start of the line.
+var ErrLineTooLong = errors.New("irc: line too long")
+
+// lineTooLongPrefixSize is how much of a line is kept in a LineTooLongError.
+const lineTooLongPrefixSize = 64
+
+// LineTooLongError is returned when reading a line longer than
+// Reader.MaxLineLength. errors.Is(err, ErrLineTooLong) can be used to check for
+// it without needing the captured data.
+type LineTooLongError struct {
+	// Length is the length of the line in bytes, including the line ending.
+	Length int
+
+	prefix []byte
+}
+
+// Prefix returns up to the first 64 bytes of the line, which can be logged to
+// help track down where it came from.
+func (e *LineTooLongError) Prefix() []byte {
+	return e.prefix
+}
+
+func (e *LineTooLongError) Error() string {
+	return fmt.Sprintf("%v: %d bytes, starting with %q", ErrLineTooLong, e.Length, e.prefix)
+}
+
+// Unwrap returns ErrLineTooLong.
+func (e *LineTooLongError) Unwrap() error {
+	return ErrLineTooLong
+}
+
 // Reader is the incoming side of a connection. The data will be
 // buffered, so do not re-use the io.Reader used to create the
 // irc.Reader.
@@ -86,8 +198,24 @@ type Reader struct {
 	// not be stable.
 	DebugCallback func(string)
 
+	// MaxLineLength is the maximum length of an incoming line in bytes,
+	// including the line ending. Longer lines are discarded without being
+	// kept in memory, and ReadMessage returns a *LineTooLongError for them,
+	// after which the next line can be read as normal. If this is zero, there
+	// is no limit. Note that IRCv3 tags can make a valid line much longer
+	// than the MaxLineLength constant.
+	MaxLineLength int
+
 	// Internal fields
 	reader *bufio.Reader
+	conn   io.Reader
+
+	// partial holds any data from an incomplete line if a read was
+	// interrupted, so it can be used when the next read completes.
+	partial string
+
+	// tooLong is set while discarding the rest of a line which is too long.
+	tooLong *LineTooLongError
 }
 
 // NewReader creates an irc.Reader from an io.Reader. Note that once a reader is
@@ -96,13 +224,19 @@ type Reader struct {
 // Message being read when you call ReadMessage.
 func NewReader(r io.Reader) *Reader {
 	return &Reader{
-		nil,
-		bufio.NewReader(r),
+		DebugCallback: nil,
+		reader:        bufio.NewReader(r),
+		conn:          r,
 	}
 }
 
 // ReadMessage returns the next message from the stream or an error.
 // It ignores empty messages.
+//
+// When the server closes the connection, it will generally send an ERROR
+// message with the reason first (see Message.IsError), so an io.EOF following
+// an ERROR is a clean close, while an io.EOF without one usually means the
+// connection was lost.
 func (r *Reader) ReadMessage() (*Message, error) {
 	var msg *Message
 
@@ -111,7 +245,7 @@ func (r *Reader) ReadMessage() (*Message, error) {
 	err := ErrZeroLengthMessage
 	for errors.Is(err, ErrZeroLengthMessage) {
 		var line string
-		line, err = r.reader.ReadString('\n')
+		line, err = r.readLine()
 		if err != nil {
 			return nil, err
 		}
@@ -125,3 +259,103 @@ func (r *Reader) ReadMessage() (*Message, error) {
 	}
 	return msg, err
 }
+
+// readLine reads the next full line, keeping any partial data if the read is
+// interrupted. Lines longer than MaxLineLength are discarded as they are read.
+func (r *Reader) readLine() (string, error) {
+	for {
+		chunk, err := r.reader.ReadSlice('\n')
+
+		switch {
+		case r.tooLong != nil:
+			r.tooLong.Length += len(chunk)
+		case r.MaxLineLength > 0 && len(r.partial)+len(chunk) > r.MaxLineLength:
+			data := r.partial + string(chunk)
+			if len(data) > lineTooLongPrefixSize {
+				data = data[:lineTooLongPrefixSize]
+			}
+
+			r.tooLong = &LineTooLongError{
+				Length: len(r.partial) + len(chunk),
+				prefix: []byte(data),
+			}
+			r.partial = ""
+		default:
+			r.partial += string(chunk)
+		}
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if r.tooLong != nil {
+			tooLong := r.tooLong
+			r.tooLong = nil
+
+			return "", tooLong
+		}
+
+		line := r.partial
+		r.partial = ""
+
+		return line, nil
+	}
+}
+
+// readDeadliner is implemented by connections which support read deadlines,
+// such as net.Conn.
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// ReadMessageContext is the same as ReadMessage, but it will return ctx.Err()
+// if the context is canceled before a message is read.
+//
+// If the underlying io.Reader has a SetReadDeadline method (like net.Conn),
+// it will be used to interrupt a blocked read as soon as the context is
+// canceled. Any data from an incomplete line will be kept and used by the next
+// read. Otherwise, cancellation will only take effect between messages.
+func (r *Reader) ReadMessageContext(ctx context.Context) (*Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	conn, ok := r.conn.(readDeadliner)
+	if !ok {
+		return r.ReadMessage()
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		select {
+		case <-ctx.Done():
+			// Setting a deadline in the past will unblock any pending reads.
+			_ = conn.SetReadDeadline(time.Unix(1, 0))
+		case <-stop:
+		}
+	}()
+
+	msg, err := r.ReadMessage()
+
+	close(stop)
+	<-done
+
+	if ctx.Err() != nil {
+		// Clear the deadline so the Reader can be used again.
+		_ = conn.SetReadDeadline(time.Time{})
+
+		if err != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	return msg, err
+}
@@ -2,10 +2,13 @@ package irc_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
+	"net"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -114,6 +117,121 @@ func TestWriteMessageError(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestWriterAddServerTime(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	w := irc.NewWriter(buf)
+	w.AddServerTime = true
+
+	before := time.Now().Truncate(time.Millisecond)
+
+	m := irc.MustParseMessage(":nick!user@host PRIVMSG #chan :hello")
+	assert.NoError(t, w.WriteMessage(m))
+
+	after := time.Now()
+
+	sent := irc.MustParseMessage(buf.String())
+	stamp := sent.Tags["time"]
+	assert.Regexp(t, `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}Z$`, stamp)
+
+	parsed, err := time.Parse(time.RFC3339Nano, stamp)
+	assert.NoError(t, err)
+	assert.False(t, parsed.Before(before), "time %s is before %s", parsed, before)
+	assert.False(t, parsed.After(after), "time %s is after %s", parsed, after)
+
+	// The original message shouldn't be modified
+	assert.False(t, m.Tags.IsSet("time"))
+
+	// Existing times should be left alone
+	buf.Reset()
+	assert.NoError(t, w.WriteMessage(irc.MustParseMessage("@time=2020-01-01T00:00:00.000Z PING :hello")))
+	assert.Equal(t, "@time=2020-01-01T00:00:00.000Z PING hello\r\n", buf.String())
+
+	// Messages without tags at all should work as well
+	buf.Reset()
+	assert.NoError(t, w.WriteMessage(&irc.Message{Command: "PING", Params: []string{"hello"}}))
+	assert.True(t, irc.MustParseMessage(buf.String()).Tags.IsSet("time"))
+
+	// Nothing should be added if it's disabled
+	buf.Reset()
+	w.AddServerTime = false
+	assert.NoError(t, w.WriteMessage(m))
+	assert.Equal(t, ":nick!user@host PRIVMSG #chan hello\r\n", buf.String())
+}
+
+// recordWriter records each call to Write separately.
+type recordWriter struct {
+	writes []string
+}
+
+func (rw *recordWriter) Write(data []byte) (int, error) {
+	rw.writes = append(rw.writes, string(data))
+	return len(data), nil
+}
+
+func TestBufferedWriter(t *testing.T) {
+	t.Parallel()
+
+	rw := &recordWriter{}
+	w := irc.NewBufferedWriter(rw, 0)
+
+	assert.NoError(t, w.Write("JOIN #a"))
+	assert.NoError(t, w.Writef("JOIN %s", "#b"))
+	assert.NoError(t, w.WriteMessage(irc.MustParseMessage("PRIVMSG #a :hello world")))
+
+	// Nothing should be sent until we flush.
+	assert.Empty(t, rw.writes)
+	assert.Equal(t, 43, w.Buffered())
+
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, []string{"JOIN #a\r\nJOIN #b\r\nPRIVMSG #a :hello world\r\n"}, rw.writes)
+	assert.Equal(t, 0, w.Buffered())
+
+	// Flushing with nothing buffered shouldn't write anything.
+	assert.NoError(t, w.Flush())
+	assert.Len(t, rw.writes, 1)
+}
+
+func TestBufferedWriterThreshold(t *testing.T) {
+	t.Parallel()
+
+	rw := &recordWriter{}
+	w := irc.NewBufferedWriter(rw, 20)
+
+	// Each of these is 9 bytes, so only two fit in the buffer at once, and
+	// the third should cause the first two to be written.
+	assert.NoError(t, w.Write("JOIN #aa"))
+	assert.NoError(t, w.Write("JOIN #bb"))
+	assert.Empty(t, rw.writes)
+	assert.NoError(t, w.Write("JOIN #cc"))
+	assert.Equal(t, []string{"JOIN #aa\r\nJOIN #bb\r\n"}, rw.writes)
+
+	// A line larger than the buffer is written directly, after anything
+	// already buffered.
+	assert.NoError(t, w.Write("PRIVMSG #chan :this line is too long"))
+	assert.Equal(t, []string{
+		"JOIN #aa\r\nJOIN #bb\r\n",
+		"JOIN #cc\r\n",
+		"PRIVMSG #chan :this line is too long\r\n",
+	}, rw.writes)
+	assert.Equal(t, 0, w.Buffered())
+
+	// Every write should be made of complete lines.
+	for _, data := range rw.writes {
+		assert.True(t, strings.HasSuffix(data, "\r\n"), "incomplete line in %q", data)
+	}
+}
+
+func TestBufferedWriterError(t *testing.T) {
+	t.Parallel()
+
+	w := irc.NewBufferedWriter(&errorWriter{}, 10)
+	assert.NoError(t, w.Write("PING a"))
+	assert.Equal(t, errorWriterErr, w.Write("PING b"))
+	assert.Equal(t, errorWriterErr, w.Flush())
+}
+
 func TestConn(t *testing.T) {
 	t.Parallel()
 
@@ -190,3 +308,134 @@ func TestDebugCallback(t *testing.T) {
 	assert.True(t, readerHit)
 	assert.True(t, writerHit)
 }
+
+func TestReaderMaxLineLength(t *testing.T) {
+	t.Parallel()
+
+	// Longer than the bufio buffer, so the line has to be read in pieces.
+	long := "PRIVMSG #chan :" + strings.Repeat("a", 5000)
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("PING :first\r\n")
+	buf.WriteString(long + "\r\n")
+	// Exactly 64 bytes, including the CRLF, then one byte over.
+	buf.WriteString("PING :" + strings.Repeat("b", 56) + "\r\n")
+	buf.WriteString("PING :" + strings.Repeat("c", 57) + "\r\n")
+	buf.WriteString("PING :last\r\n")
+
+	r := irc.NewReader(buf)
+	r.MaxLineLength = 64
+
+	m, err := r.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "first", m.Trailing())
+
+	m, err = r.ReadMessage()
+	assert.Nil(t, m)
+	assert.True(t, errors.Is(err, irc.ErrLineTooLong))
+
+	var tooLong *irc.LineTooLongError
+	if assert.True(t, errors.As(err, &tooLong)) {
+		assert.Equal(t, []byte(long[:64]), tooLong.Prefix())
+		assert.Equal(t, len(long)+2, tooLong.Length)
+		assert.Contains(t, err.Error(), "PRIVMSG #chan :aaa")
+	}
+
+	// Lines right at the limit are fine, and reading continues normally
+	// after a long line.
+	m, err = r.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("b", 56), m.Trailing())
+
+	m, err = r.ReadMessage()
+	assert.Nil(t, m)
+	if assert.True(t, errors.As(err, &tooLong)) {
+		assert.Equal(t, 65, tooLong.Length)
+		assert.Equal(t, []byte("PING :"+strings.Repeat("c", 57)+"\r"), tooLong.Prefix())
+	}
+
+	m, err = r.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "last", m.Trailing())
+
+	_, err = r.ReadMessage()
+	assert.Equal(t, io.EOF, err)
+
+	// Without a limit, long lines are read as normal
+	r = irc.NewReader(strings.NewReader(long + "\r\n"))
+	m, err = r.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("a", 5000), m.Trailing())
+}
+
+func TestReadMessageContext(t *testing.T) {
+	t.Parallel()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	r := irc.NewReader(client)
+
+	// Start a message, but don't finish it before the context is canceled.
+	wrote := make(chan struct{})
+	go func() {
+		_, _ = server.Write([]byte("PING :hel"))
+		close(wrote)
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-wrote
+		cancel()
+	}()
+
+	m, err := r.ReadMessageContext(ctx)
+	assert.Equal(t, context.Canceled, err)
+	assert.Nil(t, m)
+
+	// A canceled context should fail before trying to read.
+	m, err = r.ReadMessageContext(ctx)
+	assert.Equal(t, context.Canceled, err)
+	assert.Nil(t, m)
+
+	// The Reader should still be usable and the partial line should not be
+	// lost.
+	go func() {
+		_, _ = server.Write([]byte("lo\r\n"))
+	}()
+
+	m, err = r.ReadMessageContext(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", m.Trailing())
+}
+
+func TestReadMessageContextNoDeadline(t *testing.T) {
+	t.Parallel()
+
+	r := irc.NewReader(bytes.NewBufferString("PING :hello\r\n"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	m, err := r.ReadMessageContext(ctx)
+	assert.Equal(t, context.Canceled, err)
+	assert.Nil(t, m)
+
+	m, err = r.ReadMessageContext(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", m.Trailing())
+}
+
+func TestReadMessageErrorThenEOF(t *testing.T) {
+	t.Parallel()
+
+	r := irc.NewReader(bytes.NewBufferString("ERROR :Closing Link: host (Quit: bye)\r\n"))
+
+	m, err := r.ReadMessage()
+	assert.NoError(t, err)
+	assert.True(t, m.IsError())
+
+	_, err = r.ReadMessage()
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,110 @@
+package irc
+
+import (
+	"strings"
+	"time"
+)
+
+// ctcpDelim marks the start and end of a CTCP message.
+const ctcpDelim = "\x01"
+
+// CTCP returns the command and text of a CTCP message, which is a PRIVMSG or
+// NOTICE with its text wrapped in \x01 characters, such as "\x01VERSION\x01".
+// The command is uppercased, and the closing \x01 is optional as some clients
+// leave it off. It returns false if this isn't a CTCP message.
+func (m *Message) CTCP() (command, text string, ok bool) {
+	if (!m.IsPrivmsg() && !m.IsNotice()) || len(m.Params) < 2 {
+		return "", "", false
+	}
+
+	body := m.Trailing()
+	if !strings.HasPrefix(body, ctcpDelim) {
+		return "", "", false
+	}
+
+	body = strings.TrimSuffix(body[1:], ctcpDelim)
+	if body == "" {
+		return "", "", false
+	}
+
+	command = body
+	if idx := strings.IndexByte(body, ' '); idx != -1 {
+		command, text = body[:idx], body[idx+1:]
+	}
+
+	return strings.ToUpper(command), text, true
+}
+
+// IsCTCPRequest returns true if this is a CTCP request, which is sent as a
+// PRIVMSG. Requests may be answered with CTCPReply.
+func (m *Message) IsCTCPRequest() bool {
+	_, _, ok := m.CTCP()
+	return ok && m.IsPrivmsg()
+}
+
+// IsCTCPReply returns true if this is a CTCP reply, which is sent as a NOTICE.
+// Replies must never be answered automatically, to avoid loops between
+// clients.
+func (m *Message) IsCTCPReply() bool {
+	_, _, ok := m.CTCP()
+	return ok && m.IsNotice()
+}
+
+// CTCPReply builds a NOTICE answering a CTCP request. If text is empty, only
+// the command is sent.
+func CTCPReply(target, command, text string) *Message {
+	body := command
+	if text != "" {
+		body += " " + text
+	}
+
+	return &Message{
+		Command: "NOTICE",
+		Params:  []string{target, ctcpDelim + body + ctcpDelim},
+	}
+}
+
+// CTCPResponder builds replies to the standard CTCP queries which most clients
+// answer the same way: CLIENTINFO, PING, TIME and VERSION.
+type CTCPResponder struct {
+	// Version is sent in reply to VERSION queries.
+	Version string
+
+	// Now returns the current time, which is sent in reply to TIME queries.
+	// If it is nil, time.Now is used.
+	Now func() time.Time
+}
+
+// ctcpResponderCommands is sent in reply to CLIENTINFO queries.
+const ctcpResponderCommands = "CLIENTINFO PING TIME VERSION"
+
+// Reply returns the reply to a CTCP request, addressed to its sender. PING
+// queries are answered with the same payload, and TIME queries with the
+// current time in RFC 1123 format. It returns nil if this isn't a CTCP request
+// (so replies are never answered), the query is unknown, or there is no sender
+// to reply to.
+func (r *CTCPResponder) Reply(m *Message) *Message {
+	command, text, ok := m.CTCP()
+	if !ok || !m.IsPrivmsg() || m.Prefix == nil || m.Prefix.Name == "" {
+		return nil
+	}
+
+	switch command {
+	case "CLIENTINFO":
+		text = ctcpResponderCommands
+	case "PING":
+	case "TIME":
+		now := time.Now
+		if r.Now != nil {
+			now = r.Now
+		}
+
+		text = now().Format(time.RFC1123Z)
+	case "VERSION":
+		text = r.Version
+	default:
+		return nil
+	}
+
+	return CTCPReply(m.Prefix.Name, command, text)
+}
@@ -0,0 +1,152 @@
+package irc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestMessageCTCP(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input   string
+		Command string
+		Text    string
+		OK      bool
+		Request bool
+		Reply   bool
+	}{
+		{
+			Input:   ":nick!user@host PRIVMSG me :\x01VERSION\x01",
+			Command: "VERSION",
+			OK:      true,
+			Request: true,
+		},
+		{
+			Input:   ":me!user@host NOTICE nick :\x01VERSION go-irc 1.0\x01",
+			Command: "VERSION",
+			Text:    "go-irc 1.0",
+			OK:      true,
+			Reply:   true,
+		},
+		{
+			// The closing delimiter is optional
+			Input:   ":nick!user@host PRIVMSG #chan :\x01ACTION waves hello",
+			Command: "ACTION",
+			Text:    "waves hello",
+			OK:      true,
+			Request: true,
+		},
+		{
+			Input:   ":nick!user@host PRIVMSG me :\x01ping 1234\x01",
+			Command: "PING",
+			Text:    "1234",
+			OK:      true,
+			Request: true,
+		},
+		{
+			Input: ":nick!user@host PRIVMSG me :\x01\x01",
+		},
+		{
+			Input: ":nick!user@host PRIVMSG me :VERSION",
+		},
+		{
+			Input: ":nick!user@host TOPIC #chan :\x01VERSION\x01",
+		},
+	}
+
+	for _, testCase := range testCases {
+		m := irc.MustParseMessage(testCase.Input)
+
+		command, text, ok := m.CTCP()
+		assert.Equal(t, testCase.OK, ok, "OK didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Command, command, "Command didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Text, text, "Text didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Request, m.IsCTCPRequest(), "IsCTCPRequest didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Reply, m.IsCTCPReply(), "IsCTCPReply didn't match for %q", testCase.Input)
+	}
+}
+
+func TestCTCPReply(t *testing.T) {
+	t.Parallel()
+
+	m := irc.CTCPReply("nick", "VERSION", "go-irc 1.0")
+	assert.Equal(t, "NOTICE nick :\x01VERSION go-irc 1.0\x01", m.String())
+	assert.True(t, m.IsCTCPReply())
+	assert.False(t, m.IsCTCPRequest())
+
+	m = irc.CTCPReply("nick", "CLIENTINFO", "")
+	assert.Equal(t, "NOTICE nick \x01CLIENTINFO\x01", m.String())
+}
+
+func TestCTCPResponder(t *testing.T) {
+	t.Parallel()
+
+	responder := &irc.CTCPResponder{
+		Version: "go-irc 1.0",
+		Now: func() time.Time {
+			return time.Date(2020, time.January, 2, 3, 4, 5, 0, time.UTC)
+		},
+	}
+
+	var testCases = []struct { //nolint:gofumpt
+		Input  string
+		Expect string
+	}{
+		{
+			Input:  ":nick!user@host PRIVMSG me :\x01VERSION\x01",
+			Expect: "NOTICE nick :\x01VERSION go-irc 1.0\x01",
+		},
+		{
+			Input:  ":nick!user@host PRIVMSG me :\x01PING 1577934245 123\x01",
+			Expect: "NOTICE nick :\x01PING 1577934245 123\x01",
+		},
+		{
+			Input:  ":nick!user@host PRIVMSG me :\x01TIME\x01",
+			Expect: "NOTICE nick :\x01TIME Thu, 02 Jan 2020 03:04:05 +0000\x01",
+		},
+		{
+			Input:  ":nick!user@host PRIVMSG me :\x01CLIENTINFO\x01",
+			Expect: "NOTICE nick :\x01CLIENTINFO CLIENTINFO PING TIME VERSION\x01",
+		},
+		{
+			// Queries sent to a channel are answered privately
+			Input:  ":nick!user@host PRIVMSG #chan :\x01version\x01",
+			Expect: "NOTICE nick :\x01VERSION go-irc 1.0\x01",
+		},
+	}
+
+	for _, testCase := range testCases {
+		reply := responder.Reply(irc.MustParseMessage(testCase.Input))
+		if assert.NotNil(t, reply, "Missing reply for %q", testCase.Input) {
+			assert.Equal(t, testCase.Expect, reply.String(), "Reply didn't match for %q", testCase.Input)
+			assert.True(t, reply.IsCTCPReply())
+		}
+	}
+
+	for _, line := range []string{
+		// Unknown queries
+		":nick!user@host PRIVMSG me :\x01FINGER\x01",
+		":nick!user@host PRIVMSG #chan :\x01ACTION waves\x01",
+		// Replies must never be answered
+		":nick!user@host NOTICE me :\x01VERSION other 2.0\x01",
+		// Not CTCP at all
+		":nick!user@host PRIVMSG me :VERSION",
+		// Nobody to reply to
+		"PRIVMSG me :\x01VERSION\x01",
+	} {
+		assert.Nil(t, responder.Reply(irc.MustParseMessage(line)), "Unexpected reply for %q", line)
+	}
+
+	// The real clock is used by default
+	reply := (&irc.CTCPResponder{}).Reply(irc.MustParseMessage(":nick!user@host PRIVMSG me :\x01TIME\x01"))
+	if assert.NotNil(t, reply) {
+		_, text, _ := reply.CTCP()
+		_, err := time.Parse(time.RFC1123Z, text)
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,66 @@
+package irc
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultDeduplicatorSize is the number of msgids a Deduplicator will remember
+// if no size is given.
+const DefaultDeduplicatorSize = 1024
+
+// Deduplicator detects messages which have already been seen, based on their
+// msgid tag. This is useful when the same messages may be received more than
+// once, such as from overlapping CHATHISTORY requests. Only the most recently
+// seen msgids are remembered, so memory use is bounded. It is safe for
+// concurrent use.
+type Deduplicator struct {
+	sync.Mutex
+
+	size  int
+	order *list.List
+	seen  map[string]*list.Element
+}
+
+// NewDeduplicator creates a Deduplicator remembering at most size msgids. If
+// size is zero or negative, DefaultDeduplicatorSize is used.
+func NewDeduplicator(size int) *Deduplicator {
+	if size <= 0 {
+		size = DefaultDeduplicatorSize
+	}
+
+	return &Deduplicator{
+		size:  size,
+		order: list.New(),
+		seen:  make(map[string]*list.Element),
+	}
+}
+
+// Seen returns true if a message with the same msgid has already been seen,
+// and records the msgid if not. Messages without a msgid are never considered
+// seen. Once the Deduplicator is full, the least recently seen msgid is
+// forgotten.
+func (d *Deduplicator) Seen(m *Message) bool {
+	id, ok := m.MsgID()
+	if !ok {
+		return false
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	if elem, ok := d.seen[id]; ok {
+		d.order.MoveToFront(elem)
+		return true
+	}
+
+	d.seen[id] = d.order.PushFront(id)
+
+	if d.order.Len() > d.size {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.seen, oldest.Value.(string))
+	}
+
+	return false
+}
@@ -0,0 +1,37 @@
+package irc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func msgWithID(id string) *irc.Message {
+	return irc.MustParseMessage("@msgid=" + id + " :nick!user@host PRIVMSG #chan :hello")
+}
+
+func TestDeduplicator(t *testing.T) {
+	t.Parallel()
+
+	d := irc.NewDeduplicator(2)
+
+	assert.False(t, d.Seen(msgWithID("a")))
+	assert.True(t, d.Seen(msgWithID("a")))
+	assert.False(t, d.Seen(msgWithID("b")))
+
+	// Seeing a again makes b the oldest, so adding c should evict b.
+	assert.True(t, d.Seen(msgWithID("a")))
+	assert.False(t, d.Seen(msgWithID("c")))
+	assert.False(t, d.Seen(msgWithID("b")))
+
+	// b evicted a this time
+	assert.True(t, d.Seen(msgWithID("b")))
+	assert.False(t, d.Seen(msgWithID("a")))
+
+	// Messages without a msgid are always new
+	m := irc.MustParseMessage(":nick!user@host PRIVMSG #chan :hello")
+	assert.False(t, d.Seen(m))
+	assert.False(t, d.Seen(m))
+}
@@ -0,0 +1,10 @@
+package irc
+
+// UnregisterNumericFields removes the names registered for a numeric, so tests
+// can clean up after RegisterNumericFields.
+func UnregisterNumericFields(numeric string) {
+	numericFields.Lock()
+	defer numericFields.Unlock()
+
+	delete(numericFields.layouts, numeric)
+}
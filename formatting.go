@@ -0,0 +1,218 @@
+package irc
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Control codes used for formatting text. See
+// https://modern.ircdocs.horse/formatting.html for more information.
+const (
+	formatBold          = '\x02'
+	formatColor         = '\x03'
+	formatHexColor      = '\x04'
+	formatReset         = '\x0f'
+	formatMonospace     = '\x11'
+	formatReverse       = '\x16'
+	formatItalic        = '\x1d'
+	formatStrikethrough = '\x1e'
+	formatUnderline     = '\x1f'
+)
+
+// MaxColor is the highest color index supported by mIRC color codes.
+const MaxColor = 99
+
+// colorBreak is an empty pair of bold codes, which doesn't change the
+// formatting but stops text starting with a digit or comma from being read as
+// part of a color code.
+const colorBreak = string(formatBold) + string(formatBold)
+
+// Bold returns s wrapped in bold control codes.
+func Bold(s string) string {
+	return string(formatBold) + s + string(formatBold)
+}
+
+// Underline returns s wrapped in underline control codes.
+func Underline(s string) string {
+	return string(formatUnderline) + s + string(formatUnderline)
+}
+
+// Color returns s wrapped in color control codes using the given foreground
+// and background colors. If bg is negative, no background color is set. Color
+// indexes outside of 0-MaxColor are clamped to that range.
+//
+// The closing color code is a bare \x03, so any text appended directly after
+// it which starts with a digit or comma would be read as a new color. Use
+// FormatBuilder to join colored and plain text safely.
+func Color(fg, bg int, s string) string {
+	code := colorCode(fg, bg)
+	if bg < 0 && startsColorArgs(s) {
+		code += colorBreak
+	}
+
+	return code + s + string(formatColor)
+}
+
+// startsColorArgs returns true if s would be read as part of the arguments of
+// a color code written directly before it.
+func startsColorArgs(s string) bool {
+	return s != "" && (isDigit(s[0]) || s[0] == ',')
+}
+
+// colorCode builds the control code to set the given colors. Colors are always
+// written with two digits so text starting with a digit isn't misinterpreted
+// as part of the color.
+func colorCode(fg, bg int) string {
+	ret := string(formatColor) + formatColorIndex(fg)
+	if bg >= 0 {
+		ret += "," + formatColorIndex(bg)
+	}
+	return ret
+}
+
+func formatColorIndex(c int) string {
+	if c < 0 {
+		c = 0
+	} else if c > MaxColor {
+		c = MaxColor
+	}
+
+	if c < 10 {
+		return "0" + strconv.Itoa(c)
+	}
+
+	return strconv.Itoa(c)
+}
+
+// FormatBuilder can be used to build up formatted text from multiple pieces.
+// When the text is retrieved with String, a reset code will be appended if any
+// formatting was used so it doesn't leak into any following text. The zero
+// value is ready to use.
+type FormatBuilder struct {
+	buf       strings.Builder
+	formatted bool
+
+	// colorClosed is set when the text so far ends with a closing color
+	// code, which the next piece must not run into.
+	colorClosed bool
+}
+
+func (b *FormatBuilder) write(s string) {
+	if b.colorClosed && startsColorArgs(s) {
+		b.buf.WriteString(colorBreak)
+	}
+
+	b.buf.WriteString(s)
+	b.colorClosed = false
+}
+
+// Text appends s without any formatting. If s follows colored text and starts
+// with a digit or comma, empty formatting is inserted first so it isn't read
+// as a color.
+func (b *FormatBuilder) Text(s string) *FormatBuilder {
+	b.write(s)
+	return b
+}
+
+// Bold appends s in bold.
+func (b *FormatBuilder) Bold(s string) *FormatBuilder {
+	b.formatted = true
+	b.write(Bold(s))
+	return b
+}
+
+// Underline appends s underlined.
+func (b *FormatBuilder) Underline(s string) *FormatBuilder {
+	b.formatted = true
+	b.write(Underline(s))
+	return b
+}
+
+// Color appends s with the given colors. See Color for how the colors are
+// handled.
+func (b *FormatBuilder) Color(fg, bg int, s string) *FormatBuilder {
+	b.formatted = true
+	b.write(Color(fg, bg, s))
+	b.colorClosed = true
+	return b
+}
+
+// String returns the formatted text.
+func (b *FormatBuilder) String() string {
+	if b.formatted {
+		return b.buf.String() + string(formatReset)
+	}
+
+	return b.buf.String()
+}
+
+// StripFormatting removes all formatting control codes from the given string,
+// including colors and their arguments.
+func StripFormatting(s string) string {
+	return stripFormatting(s, true)
+}
+
+// StripColors removes only color control codes (and their arguments) from the
+// given string, leaving any other formatting in place.
+func StripColors(s string) string {
+	return stripFormatting(s, false)
+}
+
+func stripFormatting(s string, all bool) string {
+	// Because all the control codes and their arguments are ASCII, we can
+	// safely operate on bytes without breaking up any multi-byte runes.
+	buf := &strings.Builder{}
+	buf.Grow(len(s))
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case formatColor:
+			i += colorArgsLen(s[i+1:], 2, isDigit)
+		case formatHexColor:
+			i += colorArgsLen(s[i+1:], 6, isHexDigit)
+		case formatBold, formatReset, formatMonospace, formatReverse,
+			formatItalic, formatStrikethrough, formatUnderline:
+			if !all {
+				buf.WriteByte(c)
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+
+	return buf.String()
+}
+
+// colorArgsLen returns the length of the optional foreground and background
+// arguments at the start of s. Each argument may have up to maxLen characters
+// matching valid. A comma is only considered part of the arguments if it is
+// followed by a background color.
+func colorArgsLen(s string, maxLen int, valid func(byte) bool) int {
+	fg := prefixLen(s, maxLen, valid)
+	if fg == 0 || fg >= len(s) || s[fg] != ',' {
+		return fg
+	}
+
+	bg := prefixLen(s[fg+1:], maxLen, valid)
+	if bg == 0 {
+		return fg
+	}
+
+	return fg + 1 + bg
+}
+
+func prefixLen(s string, maxLen int, valid func(byte) bool) int {
+	i := 0
+	for i < len(s) && i < maxLen && valid(s[i]) {
+		i++
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
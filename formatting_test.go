@@ -0,0 +1,130 @@
+package irc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestStripFormatting(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input  string
+		Colors string
+		All    string
+	}{
+		{
+			Input:  "plain text",
+			Colors: "plain text",
+			All:    "plain text",
+		},
+		{ // Foreground only
+			Input:  "\x034red\x03 text",
+			Colors: "red text",
+			All:    "red text",
+		},
+		{ // Two digit foreground followed by digits in the text
+			Input:  "\x030412345",
+			Colors: "12345",
+			All:    "12345",
+		},
+		{ // Foreground and background
+			Input:  "\x0304,12red on blue",
+			Colors: "red on blue",
+			All:    "red on blue",
+		},
+		{ // A comma without a background is part of the text
+			Input:  "\x034,text",
+			Colors: ",text",
+			All:    ",text",
+		},
+		{ // A comma without a foreground is part of the text
+			Input:  "\x03,12text",
+			Colors: ",12text",
+			All:    ",12text",
+		},
+		{ // Hex colors
+			Input:  "\x04FF0000,00ff00text",
+			Colors: "text",
+			All:    "text",
+		},
+		{ // Other formatting
+			Input:  "\x02bold\x02 \x1funderline\x0f \x1ditalic\x1e\x11\x16",
+			Colors: "\x02bold\x02 \x1funderline\x0f \x1ditalic\x1e\x11\x16",
+			All:    "bold underline italic",
+		},
+		{ // Mixed with multi-byte text
+			Input:  "\x02\x0303,01héllo\x0f wörld",
+			Colors: "\x02héllo\x0f wörld",
+			All:    "héllo wörld",
+		},
+		{ // Trailing control codes
+			Input:  "text\x03",
+			Colors: "text",
+			All:    "text",
+		},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.Colors, irc.StripColors(testCase.Input), "StripColors failed for %q", testCase.Input)
+		assert.Equal(t, testCase.All, irc.StripFormatting(testCase.Input), "StripFormatting failed for %q", testCase.Input)
+	}
+}
+
+func TestFormatHelpers(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "\x02hello\x02", irc.Bold("hello"))
+	assert.Equal(t, "\x1fhello\x1f", irc.Underline("hello"))
+	assert.Equal(t, "\x0304hello\x03", irc.Color(4, -1, "hello"))
+	assert.Equal(t, "\x0304,12hello\x03", irc.Color(4, 12, "hello"))
+
+	// Out of range colors are clamped
+	assert.Equal(t, "\x0300,99hello\x03", irc.Color(-3, 100, "hello"))
+	assert.Equal(t, "\x0399,00hello\x03", irc.Color(150, 0, "hello"))
+
+	// Formatting should survive stripping
+	assert.Equal(t, "hello", irc.StripFormatting(irc.Color(4, 12, irc.Bold("hello"))))
+
+	// A leading comma shouldn't be read as a background color
+	assert.Equal(t, "\x0304\x02\x02,12 hello\x03", irc.Color(4, -1, ",12 hello"))
+	assert.Equal(t, ",12 hello", irc.StripFormatting(irc.Color(4, -1, ",12 hello")))
+	assert.Equal(t, "\x0304,12,hello\x03", irc.Color(4, 12, ",hello"))
+}
+
+func TestFormatBuilder(t *testing.T) {
+	t.Parallel()
+
+	b := &irc.FormatBuilder{}
+	assert.Equal(t, "", b.String())
+
+	b.Text("plain")
+	assert.Equal(t, "plain", b.String(), "Plain text shouldn't be reset")
+
+	b.Text(" ").Bold("bold").Text(" ").Underline("under").Text(" ").Color(3, -1, "green")
+	assert.Equal(t, "plain \x02bold\x02 \x1funder\x1f \x0303green\x03\x0f", b.String())
+	assert.Equal(t, "plain bold under green", irc.StripFormatting(b.String()))
+
+	// Text after a color mustn't run into the closing color code
+	var testCases = []struct { //nolint:gofumpt
+		Text     string
+		Expected string
+	}{
+		{Text: "42 items", Expected: "\x0303x\x03\x02\x0242 items\x0f"},
+		{Text: ",5 more", Expected: "\x0303x\x03\x02\x02,5 more\x0f"},
+		{Text: " 42 items", Expected: "\x0303x\x03 42 items\x0f"},
+	}
+
+	for _, testCase := range testCases {
+		b := (&irc.FormatBuilder{}).Color(3, -1, "x").Text(testCase.Text)
+		assert.Equal(t, testCase.Expected, b.String(), "Output didn't match for %q", testCase.Text)
+		assert.Equal(t, "x"+testCase.Text, irc.StripFormatting(b.String()), "Stripped output didn't match for %q", testCase.Text)
+	}
+
+	// Only the text directly after the color needs protecting
+	b = (&irc.FormatBuilder{}).Color(3, -1, "x").Bold("1").Text("2")
+	assert.Equal(t, "\x0303x\x03\x021\x022\x0f", b.String())
+}
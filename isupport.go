@@ -2,10 +2,91 @@ package irc
 
 import (
 	"errors"
+	"strconv"
 	"strings"
 	"sync"
 )
 
+// ISupport is a set of ISUPPORT values, mapping each token to its raw value.
+// Tokens without a value are mapped to an empty string. Unlike ISupportTracker,
+// this is a plain map, so it is cheap to pass around but it is not safe for
+// concurrent modification. A nil ISupport can be used when no values are known.
+type ISupport map[string]string
+
+// ParseISupport parses the tokens from a single RPL_ISUPPORT message. Servers
+// generally send multiple of these messages, so the results will need to be
+// combined, or an ISupportTracker can be used instead.
+func ParseISupport(msg *Message) (ISupport, error) {
+	if msg.Command != RPL_ISUPPORT || len(msg.Params) < 2 {
+		return nil, errors.New("malformed RPL_ISUPPORT message")
+	}
+
+	// Check for really old servers (or servers which based 005 off of rfc2812).
+	if !strings.HasSuffix(msg.Trailing(), "server") {
+		return nil, errors.New("received invalid RPL_ISUPPORT message")
+	}
+
+	ret := make(ISupport)
+
+	for _, param := range msg.Params[1 : len(msg.Params)-1] {
+		data := strings.SplitN(param, "=", 2)
+		if len(data) < 2 {
+			ret[data[0]] = ""
+			continue
+		}
+
+		// TODO: this should properly handle decoding values containing \xHH
+		ret[data[0]] = data[1]
+	}
+
+	return ret, nil
+}
+
+// TargMax returns the maximum number of targets the server allows in a single
+// command, from the TARGMAX token, such as "PRIVMSG:4,NOTICE:3,JOIN:". The
+// command is compared case-insensitively. It returns false if there is no
+// limit, either because the command is listed without a value or because it
+// isn't listed at all.
+func (s ISupport) TargMax(command string) (int, bool) {
+	for _, entry := range strings.Split(s["TARGMAX"], ",") {
+		i := strings.IndexByte(entry, ':')
+		if i == -1 || !strings.EqualFold(entry[:i], command) {
+			continue
+		}
+
+		limit, err := strconv.Atoi(entry[i+1:])
+		if err != nil || limit <= 0 {
+			return 0, false
+		}
+
+		return limit, true
+	}
+
+	return 0, false
+}
+
+// SplitTargets splits targets into groups small enough to be sent in a single
+// command according to TargMax, to be joined with commas. If there is no
+// limit, all targets are returned in a single group.
+func (s ISupport) SplitTargets(command string, targets []string) [][]string {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	limit, ok := s.TargMax(command)
+	if !ok {
+		return [][]string{targets}
+	}
+
+	ret := make([][]string, 0, (len(targets)+limit-1)/limit)
+	for len(targets) > limit {
+		ret = append(ret, targets[:limit:limit])
+		targets = targets[limit:]
+	}
+
+	return append(ret, targets)
+}
+
 // ISupportTracker tracks the ISUPPORT values returned by servers and provides a
 // convenient way to access them.
 //
@@ -36,32 +117,34 @@ func (t *ISupportTracker) Handle(msg *Message) error {
 		return nil
 	}
 
-	if len(msg.Params) < 2 {
-		return errors.New("malformed RPL_ISUPPORT message")
-	}
-
-	// Check for really old servers (or servers which based 005 off of rfc2812).
-	if !strings.HasSuffix(msg.Trailing(), "server") {
-		return errors.New("received invalid RPL_ISUPPORT message")
+	data, err := ParseISupport(msg)
+	if err != nil {
+		return err
 	}
 
 	t.Lock()
 	defer t.Unlock()
 
-	for _, param := range msg.Params[1 : len(msg.Params)-1] {
-		data := strings.SplitN(param, "=", 2)
-		if len(data) < 2 {
-			t.data[data[0]] = ""
-			continue
-		}
-
-		// TODO: this should properly handle decoding values containing \xHH
-		t.data[data[0]] = data[1]
+	for k, v := range data {
+		t.data[k] = v
 	}
 
 	return nil
 }
 
+// Snapshot returns a copy of all the currently known ISupport values.
+func (t *ISupportTracker) Snapshot() ISupport {
+	t.RLock()
+	defer t.RUnlock()
+
+	ret := make(ISupport, len(t.data))
+	for k, v := range t.data {
+		ret[k] = v
+	}
+
+	return ret
+}
+
 // IsEnabled will check for boolean ISupport values. Note that for ISupport
 // boolean true simply means the value exists.
 func (t *ISupportTracker) IsEnabled(key string) bool {
@@ -0,0 +1,96 @@
+package irc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestParseISupport(t *testing.T) {
+	t.Parallel()
+
+	data, err := irc.ParseISupport(irc.MustParseMessage(
+		":irc.example.com 005 me STATUSMSG=@+ EXCEPTS CHANTYPES=# :are supported by this server",
+	))
+	assert.NoError(t, err)
+	assert.Equal(t, irc.ISupport{
+		"STATUSMSG": "@+",
+		"EXCEPTS":   "",
+		"CHANTYPES": "#",
+	}, data)
+
+	_, err = irc.ParseISupport(irc.MustParseMessage(":irc.example.com 005 me :Try server irc.example.com, port 6667"))
+	assert.Error(t, err)
+
+	_, err = irc.ParseISupport(irc.MustParseMessage(":irc.example.com 001 me :Welcome"))
+	assert.Error(t, err)
+}
+
+func TestISupportTargMax(t *testing.T) {
+	t.Parallel()
+
+	supports := irc.ISupport{"TARGMAX": "NAMES:1,LIST:1,KICK:1,WHOIS:1,PRIVMSG:4,NOTICE:3,ACCEPT:,MONITOR:,JOIN:"}
+
+	var testCases = []struct { //nolint:gofumpt
+		Command string
+		Limit   int
+		OK      bool
+	}{
+		{Command: "PRIVMSG", Limit: 4, OK: true},
+		{Command: "privmsg", Limit: 4, OK: true},
+		{Command: "NOTICE", Limit: 3, OK: true},
+		{Command: "KICK", Limit: 1, OK: true},
+		{Command: "JOIN"},
+		{Command: "MONITOR"},
+		{Command: "PART"},
+	}
+
+	for _, testCase := range testCases {
+		limit, ok := supports.TargMax(testCase.Command)
+		assert.Equal(t, testCase.OK, ok, "OK didn't match for %q", testCase.Command)
+		assert.Equal(t, testCase.Limit, limit, "Limit didn't match for %q", testCase.Command)
+	}
+
+	_, ok := irc.ISupport{}.TargMax("PRIVMSG")
+	assert.False(t, ok)
+
+	_, ok = irc.ISupport{"TARGMAX": "PRIVMSG:lots"}.TargMax("PRIVMSG")
+	assert.False(t, ok)
+}
+
+func TestISupportSplitTargets(t *testing.T) {
+	t.Parallel()
+
+	supports := irc.ISupport{"TARGMAX": "PRIVMSG:2,JOIN:"}
+	targets := []string{"#a", "#b", "#c", "#d", "#e"}
+
+	assert.Equal(t, [][]string{{"#a", "#b"}, {"#c", "#d"}, {"#e"}}, supports.SplitTargets("PRIVMSG", targets))
+	assert.Equal(t, [][]string{targets}, supports.SplitTargets("JOIN", targets))
+	assert.Equal(t, [][]string{targets}, irc.ISupport(nil).SplitTargets("PRIVMSG", targets))
+	assert.Empty(t, supports.SplitTargets("PRIVMSG", nil))
+
+	// Appending to a group shouldn't overwrite the next one
+	groups := supports.SplitTargets("PRIVMSG", targets)
+	_ = append(groups[0], "#z")
+	assert.Equal(t, "#c", groups[1][0])
+}
+
+func TestISupportTrackerSnapshot(t *testing.T) {
+	t.Parallel()
+
+	tracker := irc.NewISupportTracker()
+	assert.NoError(t, tracker.Handle(irc.MustParseMessage(
+		":irc.example.com 005 me STATUSMSG=@+ :are supported by this server",
+	)))
+
+	snapshot := tracker.Snapshot()
+	assert.Equal(t, "@+", snapshot["STATUSMSG"])
+	assert.Equal(t, "(ov)@+", snapshot["PREFIX"])
+
+	// Modifying the snapshot should not affect the tracker.
+	snapshot["STATUSMSG"] = "@"
+	value, _ := tracker.GetRaw("STATUSMSG")
+	assert.Equal(t, "@+", value)
+}
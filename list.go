@@ -0,0 +1,61 @@
+package irc
+
+import "strconv"
+
+// ListEntry is a single channel from the reply to a LIST command.
+type ListEntry struct {
+	Channel string
+	Users   int
+	Topic   string
+}
+
+// ParseListReply parses an RPL_LIST message. ok will be false if this isn't an
+// RPL_LIST message or the user count isn't a valid number.
+//
+//	322    RPL_LIST
+//	       "<client> <channel> <# visible> :<topic>"
+func ParseListReply(m *Message) (channel string, users int, topic string, ok bool) {
+	if m.Command != RPL_LIST || len(m.Params) < 4 {
+		return "", 0, "", false
+	}
+
+	users, err := strconv.Atoi(m.Params[2])
+	if err != nil || users < 0 {
+		return "", 0, "", false
+	}
+
+	return m.Params[1], users, m.Params[3], true
+}
+
+// ListCollector accumulates the replies to a LIST command. It is not safe for
+// concurrent use.
+type ListCollector struct {
+	entries []ListEntry
+}
+
+// NewListCollector creates a new, empty ListCollector.
+func NewListCollector() *ListCollector {
+	return &ListCollector{}
+}
+
+// Add needs to be called for each incoming message. All other messages, as
+// well as malformed RPL_LIST messages, will be ignored. Once RPL_LISTEND is
+// received, the collected entries will be returned and done will be true.
+func (c *ListCollector) Add(m *Message) (entries []ListEntry, done bool) {
+	switch m.Command {
+	case RPL_LIST:
+		if channel, users, topic, ok := ParseListReply(m); ok {
+			c.entries = append(c.entries, ListEntry{
+				Channel: channel,
+				Users:   users,
+				Topic:   topic,
+			})
+		}
+	case RPL_LISTEND:
+		entries = c.entries
+		c.entries = nil
+		return entries, true
+	}
+
+	return nil, false
+}
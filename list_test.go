@@ -0,0 +1,64 @@
+package irc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestParseListReply(t *testing.T) {
+	t.Parallel()
+
+	channel, users, topic, ok := irc.ParseListReply(irc.MustParseMessage(":irc.example.com 322 lemuria #lemuria 42 :Welcome to #lemuria"))
+	assert.True(t, ok)
+	assert.Equal(t, "#lemuria", channel)
+	assert.Equal(t, 42, users)
+	assert.Equal(t, "Welcome to #lemuria", topic)
+
+	channel, users, topic, ok = irc.ParseListReply(irc.MustParseMessage(":irc.example.com 322 lemuria #empty 1 :"))
+	assert.True(t, ok)
+	assert.Equal(t, "#empty", channel)
+	assert.Equal(t, 1, users)
+	assert.Equal(t, "", topic)
+
+	for _, line := range []string{
+		":irc.example.com 322 lemuria #lemuria many :topic",
+		":irc.example.com 322 lemuria #lemuria -1 :topic",
+		":irc.example.com 322 lemuria #lemuria 42",
+		":irc.example.com 323 lemuria :End of /LIST",
+	} {
+		_, _, _, ok = irc.ParseListReply(irc.MustParseMessage(line))
+		assert.False(t, ok, "%q should not parse", line)
+	}
+}
+
+func TestListCollector(t *testing.T) {
+	t.Parallel()
+
+	c := irc.NewListCollector()
+
+	for _, line := range []string{
+		":irc.example.com 321 lemuria Channel :Users  Name",
+		":irc.example.com 322 lemuria #lemuria 42 :Welcome to #lemuria",
+		":irc.example.com 322 lemuria #broken lots :Malformed",
+		":irc.example.com 322 lemuria #go 7 :Go programming",
+	} {
+		entries, done := c.Add(irc.MustParseMessage(line))
+		assert.Nil(t, entries)
+		assert.False(t, done)
+	}
+
+	entries, done := c.Add(irc.MustParseMessage(":irc.example.com 323 lemuria :End of /LIST"))
+	assert.True(t, done)
+	assert.Equal(t, []irc.ListEntry{
+		{Channel: "#lemuria", Users: 42, Topic: "Welcome to #lemuria"},
+		{Channel: "#go", Users: 7, Topic: "Go programming"},
+	}, entries)
+
+	// The collector should be reset for the next LIST
+	entries, done = c.Add(irc.MustParseMessage(":irc.example.com 323 lemuria :End of /LIST"))
+	assert.True(t, done)
+	assert.Nil(t, entries)
+}
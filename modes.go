@@ -0,0 +1,301 @@
+package irc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// These are used when the server doesn't send the corresponding ISUPPORT
+// tokens.
+const (
+	defaultChanModes = "beI,k,l,imnpst"
+	defaultPrefix    = "(ov)@+"
+	defaultModes     = 3
+)
+
+// ModeType is the type of a channel mode, as described by the CHANMODES
+// ISUPPORT token. It determines when a mode takes a param.
+type ModeType int
+
+const (
+	// ModeTypeUnknown is used for modes the server didn't tell us about.
+	// These are assumed to never take a param.
+	ModeTypeUnknown ModeType = iota
+
+	// ModeTypeA modes add or remove an entry from a list (like bans) and
+	// always take a param.
+	ModeTypeA
+
+	// ModeTypeB modes change a setting and always take a param (like the
+	// channel key).
+	ModeTypeB
+
+	// ModeTypeC modes change a setting and only take a param when being set
+	// (like the user limit).
+	ModeTypeC
+
+	// ModeTypeD modes are flags and never take a param (like moderated).
+	ModeTypeD
+
+	// ModeTypePrefix modes give a user a membership prefix (like op or voice)
+	// and always take the nick as a param.
+	ModeTypePrefix
+)
+
+// ModeChange is a single change from a MODE message.
+type ModeChange struct {
+	// Add is true if the mode is being set and false if it is being unset.
+	Add bool
+
+	Mode  rune
+	Param string
+	Type  ModeType
+}
+
+// ModeTypes builds the mapping of channel modes to their type from the
+// CHANMODES and PREFIX values. Modes from PREFIX are given ModeTypePrefix. If
+// either value is missing, the same defaults as ParseModeChanges are used.
+func (s ISupport) ModeTypes() map[rune]ModeType {
+	ret := make(map[rune]ModeType)
+
+	chanModes, ok := s["CHANMODES"]
+	if !ok {
+		chanModes = defaultChanModes
+	}
+
+	types := []ModeType{ModeTypeA, ModeTypeB, ModeTypeC, ModeTypeD}
+	for i, modes := range strings.SplitN(chanModes, ",", len(types)+1) {
+		// Any additional types which may be added in the future are unknown
+		// to us, so we can't tell if they take a param.
+		if i >= len(types) {
+			break
+		}
+
+		for _, mode := range modes {
+			ret[mode] = types[i]
+		}
+	}
+
+	for mode := range s.PrefixModes() {
+		ret[mode] = ModeTypePrefix
+	}
+
+	return ret
+}
+
+// PrefixModes builds the mapping of membership modes to their prefix symbol
+// from the PREFIX value, such as 'o' to '@'. If PREFIX is missing, the default
+// of (ov)@+ is used. An empty map is returned if the value is malformed.
+func (s ISupport) PrefixModes() map[rune]rune {
+	prefix, ok := s["PREFIX"]
+	if !ok {
+		prefix = defaultPrefix
+	}
+
+	i := strings.IndexByte(prefix, ')')
+	if len(prefix) == 0 || prefix[0] != '(' || i < 0 {
+		return map[rune]rune{}
+	}
+
+	modes := []rune(prefix[1:i])
+	symbols := []rune(prefix[i+1:])
+	if len(modes) != len(symbols) {
+		return map[rune]rune{}
+	}
+
+	ret := make(map[rune]rune, len(modes))
+	for k := range modes {
+		ret[modes[k]] = symbols[k]
+	}
+
+	return ret
+}
+
+// ParseModeChanges parses a channel mode string (like "+ov-b") and its params
+// into individual changes. The CHANMODES and PREFIX values in supports are used
+// to determine which modes take a param. If they are missing, common defaults
+// are used.
+func ParseModeChanges(supports ISupport, modes string, params []string) ([]ModeChange, error) {
+	types := supports.ModeTypes()
+
+	var ret []ModeChange
+	add := true
+
+	for _, mode := range modes {
+		switch mode {
+		case '+':
+			add = true
+			continue
+		case '-':
+			add = false
+			continue
+		}
+
+		change := ModeChange{Add: add, Mode: mode, Type: types[mode]}
+
+		if modeHasParam(change.Type, add) {
+			if len(params) == 0 {
+				return nil, fmt.Errorf("missing param for mode %c", mode)
+			}
+
+			change.Param, params = params[0], params[1:]
+		}
+
+		ret = append(ret, change)
+	}
+
+	return ret, nil
+}
+
+// ModeMessage builds the MODE messages needed to make the given changes to
+// target, which is the inverse of ParseModeChanges. Each message has at most
+// as many mode params as the MODES value in supports allows, defaulting to 3
+// if it is missing. If MODES is present without a value, there is no limit and
+// a single message is returned.
+//
+// A change's Param is sent only if it is non-empty, regardless of its Type, so
+// a list mode without a param (like "+b") can be used to request the list.
+// Changes without a param don't count towards the limit.
+func ModeMessage(target string, changes []ModeChange, supports ISupport) []*Message {
+	limit := supports.intValue("MODES", defaultModes)
+	if value, ok := supports["MODES"]; ok && value == "" {
+		limit = 0
+	}
+
+	var ret []*Message
+
+	var modes strings.Builder
+	var params []string
+	var count int
+	var add bool
+
+	flush := func() {
+		if modes.Len() == 0 {
+			return
+		}
+
+		ret = append(ret, &Message{
+			Command: "MODE",
+			Params:  append([]string{target, modes.String()}, params...),
+		})
+
+		modes.Reset()
+		params = nil
+		count = 0
+	}
+
+	for _, change := range changes {
+		if change.Param != "" && limit > 0 && count == limit {
+			flush()
+		}
+
+		if modes.Len() == 0 || change.Add != add {
+			add = change.Add
+			if add {
+				modes.WriteByte('+')
+			} else {
+				modes.WriteByte('-')
+			}
+		}
+
+		modes.WriteRune(change.Mode)
+
+		if change.Param != "" {
+			params = append(params, change.Param)
+			count++
+		}
+	}
+
+	flush()
+
+	return ret
+}
+
+func modeHasParam(modeType ModeType, add bool) bool {
+	switch modeType {
+	case ModeTypeA, ModeTypeB, ModeTypePrefix:
+		return true
+	case ModeTypeC:
+		return add
+	case ModeTypeUnknown, ModeTypeD:
+	}
+
+	return false
+}
+
+// ChannelModes tracks the current modes of a channel.
+type ChannelModes struct {
+	// Lists contains the entries for list modes (ModeTypeA), such as bans.
+	Lists map[rune][]string
+
+	// Params contains the values of modes which take a param when set
+	// (ModeTypeB and ModeTypeC), such as the key or user limit.
+	Params map[rune]string
+
+	// Flags contains all set modes which take no param (ModeTypeD and
+	// ModeTypeUnknown), such as moderated.
+	Flags map[rune]struct{}
+}
+
+// NewChannelModes creates a new, empty ChannelModes.
+func NewChannelModes() *ChannelModes {
+	return &ChannelModes{
+		Lists:  make(map[rune][]string),
+		Params: make(map[rune]string),
+		Flags:  make(map[rune]struct{}),
+	}
+}
+
+// Apply updates the modes with the given changes. Changes to membership modes
+// (ModeTypePrefix) are ignored, as they apply to users rather than the channel.
+//
+// Unsetting a list mode removes the entry exactly matching the param, so "-b
+// *!*@host" will only remove a "*!*@host" ban. Entries are compared as-is,
+// without any case folding.
+func (c *ChannelModes) Apply(changes []ModeChange) {
+	for _, change := range changes {
+		switch change.Type {
+		case ModeTypeA:
+			c.applyList(change)
+		case ModeTypeB, ModeTypeC:
+			if change.Add {
+				c.Params[change.Mode] = change.Param
+			} else {
+				delete(c.Params, change.Mode)
+			}
+		case ModeTypeUnknown, ModeTypeD:
+			if change.Add {
+				c.Flags[change.Mode] = struct{}{}
+			} else {
+				delete(c.Flags, change.Mode)
+			}
+		case ModeTypePrefix:
+		}
+	}
+}
+
+func (c *ChannelModes) applyList(change ModeChange) {
+	entries := c.Lists[change.Mode]
+
+	for i, entry := range entries {
+		if entry == change.Param {
+			if change.Add {
+				// The entry is already in the list
+				return
+			}
+
+			entries = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+
+	if change.Add {
+		entries = append(entries, change.Param)
+	}
+
+	if len(entries) == 0 {
+		delete(c.Lists, change.Mode)
+	} else {
+		c.Lists[change.Mode] = entries
+	}
+}
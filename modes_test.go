@@ -0,0 +1,174 @@
+package irc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestParseModeChanges(t *testing.T) {
+	t.Parallel()
+
+	supports := irc.ISupport{
+		"CHANMODES": "beI,k,l,imnpst",
+		"PREFIX":    "(qov)~@+",
+	}
+
+	changes, err := irc.ParseModeChanges(supports, "+ob-l+kmv-b", []string{"nick", "*!*@bad", "secret", "voiced", "*!*@old"})
+	assert.NoError(t, err)
+	assert.Equal(t, []irc.ModeChange{
+		{Add: true, Mode: 'o', Param: "nick", Type: irc.ModeTypePrefix},
+		{Add: true, Mode: 'b', Param: "*!*@bad", Type: irc.ModeTypeA},
+		{Add: false, Mode: 'l', Type: irc.ModeTypeC},
+		{Add: true, Mode: 'k', Param: "secret", Type: irc.ModeTypeB},
+		{Add: true, Mode: 'm', Type: irc.ModeTypeD},
+		{Add: true, Mode: 'v', Param: "voiced", Type: irc.ModeTypePrefix},
+		{Add: false, Mode: 'b', Param: "*!*@old", Type: irc.ModeTypeA},
+	}, changes)
+
+	// Missing params are an error
+	_, err = irc.ParseModeChanges(supports, "+k", nil)
+	assert.Error(t, err)
+
+	// Defaults are used without ISupport values
+	changes, err = irc.ParseModeChanges(nil, "+lZ", []string{"50"})
+	assert.NoError(t, err)
+	assert.Equal(t, []irc.ModeChange{
+		{Add: true, Mode: 'l', Param: "50", Type: irc.ModeTypeC},
+		{Add: true, Mode: 'Z', Type: irc.ModeTypeUnknown},
+	}, changes)
+}
+
+func TestModeMessage(t *testing.T) {
+	t.Parallel()
+
+	changes := []irc.ModeChange{
+		{Add: true, Mode: 'o', Param: "alice", Type: irc.ModeTypePrefix},
+		{Add: true, Mode: 'o', Param: "bob", Type: irc.ModeTypePrefix},
+		{Add: true, Mode: 'm', Type: irc.ModeTypeD},
+		{Add: false, Mode: 'v', Param: "carol", Type: irc.ModeTypePrefix},
+		{Add: false, Mode: 'b', Param: "*!*@bad", Type: irc.ModeTypeA},
+		{Add: true, Mode: 'l', Param: "50", Type: irc.ModeTypeC},
+		{Add: false, Mode: 't', Type: irc.ModeTypeD},
+	}
+
+	lines := func(msgs []*irc.Message) []string {
+		var ret []string
+		for _, m := range msgs {
+			ret = append(ret, m.String())
+		}
+		return ret
+	}
+
+	// The default limit is 3 params per line
+	assert.Equal(t, []string{
+		"MODE #chan +oom-v alice bob carol",
+		"MODE #chan -b+l-t *!*@bad 50",
+	}, lines(irc.ModeMessage("#chan", changes, nil)))
+
+	assert.Equal(t, []string{
+		"MODE #chan +oom alice bob",
+		"MODE #chan -vb carol *!*@bad",
+		"MODE #chan +l-t 50",
+	}, lines(irc.ModeMessage("#chan", changes, irc.ISupport{"MODES": "2"})))
+
+	// MODES without a value means there is no limit
+	assert.Equal(t, []string{
+		"MODE #chan +oom-vb+l-t alice bob carol *!*@bad 50",
+	}, lines(irc.ModeMessage("#chan", changes, irc.ISupport{"MODES": ""})))
+
+	// Changes without params don't count towards the limit
+	assert.Equal(t, []string{
+		"MODE #chan +o-m+n alice",
+	}, lines(irc.ModeMessage("#chan", []irc.ModeChange{
+		{Add: true, Mode: 'o', Param: "alice", Type: irc.ModeTypePrefix},
+		{Add: false, Mode: 'm', Type: irc.ModeTypeD},
+		{Add: true, Mode: 'n', Type: irc.ModeTypeD},
+	}, irc.ISupport{"MODES": "1"})))
+
+	assert.Empty(t, irc.ModeMessage("#chan", nil, nil))
+
+	// The messages should parse back into the same changes
+	supports := irc.ISupport{"MODES": "2"}
+	var parsed []irc.ModeChange
+	for _, m := range irc.ModeMessage("#chan", changes, supports) {
+		more, err := irc.ParseModeChanges(supports, m.Params[1], m.Params[2:])
+		assert.NoError(t, err)
+		parsed = append(parsed, more...)
+	}
+	assert.Equal(t, changes, parsed)
+}
+
+func TestChannelModesApply(t *testing.T) {
+	t.Parallel()
+
+	modes := irc.NewChannelModes()
+
+	changes, err := irc.ParseModeChanges(nil, "+ntbbkl", []string{"*!*@a", "*!*@b", "key", "10"})
+	assert.NoError(t, err)
+	modes.Apply(changes)
+
+	assert.Equal(t, map[rune][]string{'b': {"*!*@a", "*!*@b"}}, modes.Lists)
+	assert.Equal(t, map[rune]string{'k': "key", 'l': "10"}, modes.Params)
+	assert.Equal(t, map[rune]struct{}{'n': {}, 't': {}}, modes.Flags)
+
+	// Membership modes are ignored, removing a list entry only removes the
+	// matching entry and adding a duplicate entry is a no-op.
+	changes, err = irc.ParseModeChanges(nil, "+o-b+b-t-kl", []string{"nick", "*!*@a", "*!*@b", "key"})
+	assert.NoError(t, err)
+	modes.Apply(changes)
+
+	assert.Equal(t, map[rune][]string{'b': {"*!*@b"}}, modes.Lists)
+	assert.Equal(t, map[rune]string{}, modes.Params)
+	assert.Equal(t, map[rune]struct{}{'n': {}}, modes.Flags)
+
+	// Removing the last entry should remove the list
+	modes.Apply([]irc.ModeChange{{Add: false, Mode: 'b', Param: "*!*@b", Type: irc.ModeTypeA}})
+	assert.Equal(t, map[rune][]string{}, modes.Lists)
+}
+
+func TestISupportModeTypes(t *testing.T) {
+	t.Parallel()
+
+	supports, err := irc.ParseISupport(irc.MustParseMessage(
+		":tantalum.libera.chat 005 lemuria CALLERID=g WHOX ETRACE FNC SAFELIST ELIST=CMNTU KNOCK MONITOR=100 CHANTYPES=# EXCEPTS INVEX CHANMODES=eIbq,k,flj,CFLMPQRSTcgimnprstuz :are supported by this server",
+	))
+	assert.NoError(t, err)
+
+	// PREFIX wasn't in this message, so the default should be used
+	assert.Equal(t, map[rune]rune{'o': '@', 'v': '+'}, supports.PrefixModes())
+
+	supports["PREFIX"] = "(qaohv)~&@%+"
+	assert.Equal(t, map[rune]rune{
+		'q': '~',
+		'a': '&',
+		'o': '@',
+		'h': '%',
+		'v': '+',
+	}, supports.PrefixModes())
+
+	types := supports.ModeTypes()
+	for mode, expected := range map[rune]irc.ModeType{
+		'e': irc.ModeTypeA,
+		'b': irc.ModeTypeA,
+		'k': irc.ModeTypeB,
+		'f': irc.ModeTypeC,
+		'l': irc.ModeTypeC,
+		'j': irc.ModeTypeC,
+		'C': irc.ModeTypeD,
+		'z': irc.ModeTypeD,
+		'o': irc.ModeTypePrefix,
+		'h': irc.ModeTypePrefix,
+	} {
+		assert.Equal(t, expected, types[mode], "type didn't match for %q", mode)
+	}
+
+	_, ok := types['x']
+	assert.False(t, ok)
+
+	// Broken PREFIX values shouldn't be trusted at all
+	assert.Empty(t, irc.ISupport{"PREFIX": "(ov)@"}.PrefixModes())
+	assert.Empty(t, irc.ISupport{"PREFIX": "ov@+"}.PrefixModes())
+}
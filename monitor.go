@@ -0,0 +1,95 @@
+package irc
+
+import "strings"
+
+// MonitorAdd builds the MONITOR messages needed to add the given nicks to the
+// monitor list. The nicks will be split across as many messages as needed to
+// keep each line under MaxLineLength.
+//
+// Note that the MONITOR ISUPPORT token limits the total size of the monitor
+// list, not the number of nicks per line, so it is up to the caller to ensure
+// that limit is not exceeded.
+func MonitorAdd(nicks ...string) []*Message {
+	return monitorMessages("+", nicks)
+}
+
+// MonitorDel builds the MONITOR messages needed to remove the given nicks from
+// the monitor list. Like MonitorAdd, the nicks will be split across as many
+// messages as needed.
+func MonitorDel(nicks ...string) []*Message {
+	return monitorMessages("-", nicks)
+}
+
+func monitorMessages(modifier string, nicks []string) []*Message {
+	// The line looks like "MONITOR + nick1,nick2\r\n"
+	budget := MaxLineLength - len("MONITOR "+modifier+" \r\n")
+
+	var ret []*Message
+	for _, chunk := range chunkList(nicks, budget) {
+		ret = append(ret, &Message{
+			Command: "MONITOR",
+			Params:  []string{modifier, strings.Join(chunk, ",")},
+		})
+	}
+
+	return ret
+}
+
+// chunkList splits the given items into groups which will fit in budget bytes
+// when joined by commas. An item which is too long on its own will be put in
+// its own group rather than dropped.
+func chunkList(items []string, budget int) [][]string {
+	var ret [][]string
+	var current []string
+	currentLen := 0
+
+	for _, item := range items {
+		// If this isn't the first item, we need room for the comma as well.
+		if len(current) > 0 && currentLen+1+len(item) > budget {
+			ret = append(ret, current)
+			current = nil
+			currentLen = 0
+		}
+
+		if len(current) > 0 {
+			currentLen++
+		}
+
+		current = append(current, item)
+		currentLen += len(item)
+	}
+
+	if len(current) > 0 {
+		ret = append(ret, current)
+	}
+
+	return ret
+}
+
+// ParseMonitorReply parses the RPL_MONONLINE and RPL_MONOFFLINE numerics. The
+// returned nicks will have any user and host stripped. ok will be false if the
+// message is not one of these numerics.
+func ParseMonitorReply(m *Message) (nicks []string, online bool, ok bool) {
+	switch m.Command {
+	case RPL_MONONLINE:
+		online = true
+	case RPL_MONOFFLINE:
+		online = false
+	default:
+		return nil, false, false
+	}
+
+	if len(m.Params) < 2 {
+		return nil, false, false
+	}
+
+	for _, target := range strings.Split(m.Trailing(), ",") {
+		if target == "" {
+			continue
+		}
+
+		nicks = append(nicks, ParsePrefix(target).Name)
+	}
+
+	return nicks, online, true
+}
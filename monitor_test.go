@@ -0,0 +1,65 @@
+package irc_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestMonitorAddDel(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, irc.MonitorAdd())
+
+	msgs := irc.MonitorAdd("alice", "bob")
+	if assert.Len(t, msgs, 1) {
+		assert.Equal(t, "MONITOR + alice,bob", msgs[0].String())
+	}
+
+	msgs = irc.MonitorDel("alice")
+	if assert.Len(t, msgs, 1) {
+		assert.Equal(t, "MONITOR - alice", msgs[0].String())
+	}
+
+	// Enough nicks to need multiple lines
+	var nicks []string
+	for i := 0; i < 100; i++ {
+		nicks = append(nicks, fmt.Sprintf("nickname%03d", i))
+	}
+
+	msgs = irc.MonitorAdd(nicks...)
+	assert.True(t, len(msgs) > 1, "Expected multiple messages")
+
+	var seen []string
+	for _, m := range msgs {
+		line := m.String() + "\r\n"
+		assert.True(t, len(line) <= irc.MaxLineLength, "Line too long: %d", len(line))
+		assert.Equal(t, "+", m.Param(0))
+		seen = append(seen, strings.Split(m.Param(1), ",")...)
+	}
+	assert.Equal(t, nicks, seen)
+}
+
+func TestParseMonitorReply(t *testing.T) {
+	t.Parallel()
+
+	nicks, online, ok := irc.ParseMonitorReply(irc.MustParseMessage(":irc.example.com 730 me :alice!a@host,bob!b@host"))
+	assert.True(t, ok)
+	assert.True(t, online)
+	assert.Equal(t, []string{"alice", "bob"}, nicks)
+
+	nicks, online, ok = irc.ParseMonitorReply(irc.MustParseMessage(":irc.example.com 731 me :alice,bob"))
+	assert.True(t, ok)
+	assert.False(t, online)
+	assert.Equal(t, []string{"alice", "bob"}, nicks)
+
+	_, _, ok = irc.ParseMonitorReply(irc.MustParseMessage(":irc.example.com 732 me :alice,bob"))
+	assert.False(t, ok)
+
+	_, _, ok = irc.ParseMonitorReply(irc.MustParseMessage(":irc.example.com 730"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,63 @@
+package irc
+
+import "sync"
+
+// numericFields maps numerics to the names of their params, in order. An empty
+// name marks a param which can't be looked up.
+var numericFields = struct {
+	sync.RWMutex
+	layouts map[string][]string
+}{
+	layouts: map[string][]string{
+		RPL_WELCOME:        {"client", "message"},
+		RPL_AWAY:           {"client", "nick", "message"},
+		RPL_WHOISUSER:      {"client", "nick", "user", "host", "", "realname"},
+		RPL_NOTOPIC:        {"client", "channel", "message"},
+		RPL_TOPIC:          {"client", "channel", "topic"},
+		rplTopicWhoTime:    {"client", "channel", "setter", "time"},
+		RPL_INVITING:       {"client", "nick", "channel"},
+		RPL_NAMREPLY:       {"client", "symbol", "channel", "names"},
+		RPL_ENDOFNAMES:     {"client", "channel", "message"},
+		ERR_NOSUCHNICK:     {"client", "nick", "message"},
+		ERR_NOSUCHCHANNEL:  {"client", "channel", "message"},
+		ERR_NICKNAMEINUSE:  {"client", "nick", "message"},
+		ERR_CHANNELISFULL:  {"client", "channel", "message"},
+		ERR_INVITEONLYCHAN: {"client", "channel", "message"},
+		ERR_BANNEDFROMCHAN: {"client", "channel", "message"},
+		ERR_BADCHANNELKEY:  {"client", "channel", "message"},
+	},
+}
+
+// RegisterNumericFields sets the names of the params of the given numeric, in
+// order, so they can be looked up with Message.NumericField. Use an empty name
+// for params which shouldn't be looked up. Any existing names for the numeric
+// are replaced.
+func RegisterNumericFields(numeric string, fields ...string) {
+	numericFields.Lock()
+	defer numericFields.Unlock()
+
+	numericFields.layouts[numeric] = append([]string(nil), fields...)
+}
+
+// NumericField returns the param with the given name from a numeric reply, such
+// as "channel" from RPL_TOPIC. The first param of every registered numeric is
+// named "client". It returns false if the numeric hasn't been registered with
+// RegisterNumericFields, it doesn't have a param with that name, or the server
+// didn't send enough params.
+func (m *Message) NumericField(name string) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+
+	numericFields.RLock()
+	layout := numericFields.layouts[m.Command]
+	numericFields.RUnlock()
+
+	for i, field := range layout {
+		if field == name && i < len(m.Params) {
+			return m.Params[i], true
+		}
+	}
+
+	return "", false
+}
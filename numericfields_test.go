@@ -0,0 +1,91 @@
+package irc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestMessageNumericField(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input string
+		Field string
+		Value string
+		OK    bool
+	}{
+		{
+			Input: ":irc.example.com 332 lemuria #lemuria :the topic",
+			Field: "channel",
+			Value: "#lemuria",
+			OK:    true,
+		},
+		{
+			Input: ":irc.example.com 332 lemuria #lemuria :the topic",
+			Field: "topic",
+			Value: "the topic",
+			OK:    true,
+		},
+		{
+			Input: ":irc.example.com 474 lemuria #lemuria :Cannot join channel (+b)",
+			Field: "channel",
+			Value: "#lemuria",
+			OK:    true,
+		},
+		{
+			Input: ":irc.example.com 433 * lemuria :Nickname is already in use",
+			Field: "nick",
+			Value: "lemuria",
+			OK:    true,
+		},
+		{
+			Input: ":irc.example.com 433 * lemuria :Nickname is already in use",
+			Field: "client",
+			Value: "*",
+			OK:    true,
+		},
+		{
+			// Unknown field
+			Input: ":irc.example.com 332 lemuria #lemuria :the topic",
+			Field: "nick",
+		},
+		{
+			// Unnamed params can't be looked up
+			Input: ":irc.example.com 311 lemuria nick user host * :Real Name",
+			Field: "",
+		},
+		{
+			// Not enough params
+			Input: ":irc.example.com 332 lemuria #lemuria",
+			Field: "topic",
+		},
+		{
+			// Unregistered numeric
+			Input: ":irc.example.com 999 lemuria #lemuria :something",
+			Field: "channel",
+		},
+	}
+
+	for _, testCase := range testCases {
+		value, ok := irc.MustParseMessage(testCase.Input).NumericField(testCase.Field)
+		assert.Equal(t, testCase.OK, ok, "ok didn't match for %q in %q", testCase.Field, testCase.Input)
+		assert.Equal(t, testCase.Value, value, "value didn't match for %q in %q", testCase.Field, testCase.Input)
+	}
+}
+
+func TestRegisterNumericFields(t *testing.T) {
+	t.Parallel()
+
+	// 997 isn't used by any other test, and isn't registered by default.
+	irc.RegisterNumericFields("997", "client", "channel", "count", "message")
+	t.Cleanup(func() { irc.UnregisterNumericFields("997") })
+
+	m := irc.MustParseMessage(":irc.example.com 997 lemuria #lemuria 42 :some text")
+
+	value, ok := m.NumericField("count")
+	assert.True(t, ok)
+	assert.Equal(t, "42", value)
+}
@@ -3,9 +3,11 @@ package irc
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"reflect"
 	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
 var tagDecodeSlashMap = map[rune]rune{
@@ -24,8 +26,30 @@ var tagEncodeMap = map[rune]string{
 	'\n': "\\n",
 }
 
+// AlphabetizeTagMaps controls whether tags are sorted alphabetically when
+// serializing with TagSortDefault.
 var AlphabetizeTagMaps = true
 
+// TagSortMode controls the order tags are written in when serializing.
+type TagSortMode int
+
+const (
+	// TagSortDefault sorts tags alphabetically if AlphabetizeTagMaps is true
+	// and leaves them in map order otherwise. For parsed messages whose tags
+	// have not been modified, the original tag string is used instead.
+	TagSortDefault TagSortMode = iota
+
+	// TagSortNone leaves tags in map order, which is random.
+	TagSortNone
+
+	// TagSortAlphabetical sorts all tags alphabetically.
+	TagSortAlphabetical
+
+	// TagSortServerFirst sorts server tags alphabetically, followed by client
+	// tags (those starting with a +) alphabetically.
+	TagSortServerFirst
+)
+
 var (
 	// ErrZeroLengthMessage is returned when parsing if the input is
 	// zero-length.
@@ -42,11 +66,108 @@ var (
 	// ErrMissingCommand is returned when parsing if there is no
 	// command in the parsed message.
 	ErrMissingCommand = errors.New("irc: missing message command")
+
+	// ErrTooManyParams is returned when parsing if the message has more
+	// params than allowed by ParseOptions.MaxParams.
+	ErrTooManyParams = errors.New("irc: too many message params")
+
+	// ErrExtraSpaceAfterTags is returned when parsing with
+	// ParseOptions.Strict if the tags are followed by more than one space.
+	ErrExtraSpaceAfterTags = errors.New("irc: extra space after tags")
+
+	// ErrExtraSpaceAfterPrefix is returned when parsing with
+	// ParseOptions.Strict if the prefix is followed by more than one space.
+	ErrExtraSpaceAfterPrefix = errors.New("irc: extra space after prefix")
+
+	// ErrInvalidTagEscape is returned when parsing with ParseOptions.Strict
+	// if a tag value contains a backslash which isn't part of a valid escape
+	// sequence.
+	ErrInvalidTagEscape = errors.New("irc: invalid tag escape")
+
+	// ErrInvalidTagKey is returned when parsing with ParseOptions.Strict if a
+	// tag key contains non-ASCII characters.
+	ErrInvalidTagKey = errors.New("irc: invalid tag key")
+
+	// ErrEmbeddedNewline is returned by Raw if the line contains a CR or LF
+	// anywhere other than at the end.
+	ErrEmbeddedNewline = errors.New("irc: line contains an embedded newline")
 )
 
+// MaxLineLength is the maximum length of a line allowed by RFC 1459, including
+// the trailing CRLF but not including any IRCv3 tags.
+const MaxLineLength = 512
+
+// RFC1459MaxParams is the maximum number of params (including the trailing
+// param) allowed in a message by RFC 1459. It is meant to be used as the value
+// for ParseOptions.MaxParams.
+const RFC1459MaxParams = 15
+
+// ParseOptions can be used with ParseMessageOpts to change how messages are
+// parsed. The zero value matches the behavior of ParseMessage.
+type ParseOptions struct {
+	// MaxParams is the maximum number of params a message may have. If this
+	// is zero, there is no limit.
+	MaxParams int
+
+	// PreserveCommandCase leaves the command exactly as it was received,
+	// rather than uppercasing it so commands can be compared without
+	// worrying about case.
+	PreserveCommandCase bool
+
+	// GreedyTrailing works around servers which don't prefix the trailing
+	// param with a colon, such as sending "PRIVMSG #chan hello world". For
+	// a few common commands which end in free-form text (PRIVMSG, NOTICE,
+	// TOPIC, PART, KICK, QUIT, AWAY and ERROR), everything after the
+	// command's fixed params is treated as a single trailing param if there
+	// is no colon. Other commands are unaffected.
+	//
+	// This isn't standard and changes the meaning of valid messages, so it
+	// should only be enabled for servers known to need it.
+	GreedyTrailing bool
+
+	// Strict rejects messages which don't follow the message format
+	// exactly, rather than trying to make sense of them. Currently, this
+	// means the tags and prefix must be followed by exactly one space, tag
+	// keys must be ASCII, and tag values must only contain valid escape
+	// sequences (see ParseTagValue). Without this, non-ASCII tag keys are
+	// kept exactly as they were received.
+	Strict bool
+
+	// SkipTags avoids the cost of parsing tags when they aren't needed. The
+	// tag section is still skipped over, but Tags will be nil, so it must
+	// not be written to without being replaced first. The tags will not be
+	// included if the message is serialized again.
+	SkipTags bool
+
+	// TrimParamWhitespace removes trailing spaces from the trailing param,
+	// for servers which pad it. Spaces inside the param are kept, as are
+	// any other params. This isn't standard, as trailing spaces are part of
+	// the param, so it is mostly useful when comparing against stored
+	// messages.
+	TrimParamWhitespace bool
+}
+
+// greedyTrailingParams maps commands to the number of params they have before
+// their trailing text, for use with ParseOptions.GreedyTrailing.
+var greedyTrailingParams = map[string]int{
+	"PRIVMSG": 1,
+	"NOTICE":  1,
+	"TOPIC":   1,
+	"PART":    1,
+	"KICK":    2,
+	"QUIT":    0,
+	"AWAY":    0,
+	"ERROR":   0,
+}
+
 // ParseTagValue parses an encoded tag value as a string. If you need to set a
 // tag, you probably want to just set the string itself, so it will be encoded
 // properly.
+//
+// The only valid escape sequences are \: (;), \s (space), \\ (\), \r (CR)
+// and \n (LF). As required by the spec, a backslash followed by any other
+// character is dropped, leaving the character, and a backslash at the end of
+// the value is dropped entirely. ParseOptions.Strict rejects these instead.
 func ParseTagValue(v string) string {
 	ret := &bytes.Buffer{}
 
@@ -79,6 +200,47 @@ func ParseTagValue(v string) string {
 	return ret.String()
 }
 
+// validTagEscapes checks if every backslash in an encoded tag section starts
+// one of the escape sequences understood by ParseTagValue.
+func validTagEscapes(tags string) bool {
+	for i := 0; i < len(tags); i++ {
+		if tags[i] != '\\' {
+			continue
+		}
+
+		// A backslash at the end of a value is invalid, as is one followed
+		// by anything which isn't a known escape.
+		if i+1 >= len(tags) {
+			return false
+		}
+
+		if _, ok := tagDecodeSlashMap[rune(tags[i+1])]; !ok {
+			return false
+		}
+
+		i++
+	}
+
+	return true
+}
+
+// validTagKeys checks that every key in an encoded tag section is ASCII.
+func validTagKeys(tags string) bool {
+	for _, tag := range strings.Split(tags, ";") {
+		if idx := strings.IndexByte(tag, '='); idx != -1 {
+			tag = tag[:idx]
+		}
+
+		for i := 0; i < len(tag); i++ {
+			if tag[i] >= utf8.RuneSelf {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
 // EncodeTagValue converts a raw string to the format in the connection.
 func EncodeTagValue(v string) string {
 	ret := &bytes.Buffer{}
@@ -94,7 +256,16 @@ func EncodeTagValue(v string) string {
 	return ret.String()
 }
 
-// Tags represents the IRCv3 message tags.
+// Tags represents the IRCv3 message tags. Values are stored unescaped.
+//
+// A tag without a value (@foo) and a tag with an empty value (@foo=) are
+// equivalent according to the spec, so both are stored as an empty string.
+// Use IsSet to check if a tag is present and HasValue to check if it has a
+// non-empty value. A parsed Message remembers which tags were sent with an
+// explicitly empty value, which can be checked with Message.TagHasValue, and
+// writes them back as @foo= as long as their value is still empty, even if
+// other tags are modified. Any other tag with an empty value is written as
+// @foo.
 type Tags map[string]string
 
 // ParseTags takes a tag string and parses it into a tag map. It will
@@ -130,20 +301,22 @@ func (t Tags) Copy() Tags {
 
 // String ensures this is stringable.
 func (t Tags) String() string {
-	buf := &bytes.Buffer{}
+	return t.StringSorted(TagSortDefault)
+}
 
-	keys := make([]string, len(t))
-	i := 0
-	for k, _ := range t {
-		keys[i] = k
-		i++
-	}
+// StringSorted is the same as String, but the tags will be written in the
+// order specified by the given TagSortMode.
+func (t Tags) StringSorted(mode TagSortMode) string {
+	return t.stringSorted(mode, "")
+}
 
-	if AlphabetizeTagMaps {
-		sort.Strings(keys)
-	}
+// stringSorted implements StringSorted. Tags with an empty value which were
+// explicitly empty in original, a tag string as received from the server, are
+// written with a trailing '='.
+func (t Tags) stringSorted(mode TagSortMode, original string) string {
+	buf := &bytes.Buffer{}
 
-	for _, k := range keys {
+	for _, k := range t.sortedKeys(mode) {
 		buf.WriteByte(';')
 		buf.WriteString(k)
 
@@ -151,6 +324,8 @@ func (t Tags) String() string {
 		if v != "" {
 			buf.WriteByte('=')
 			buf.WriteString(EncodeTagValue(v))
+		} else if original != "" && explicitlyEmptyTag(original, k) {
+			buf.WriteByte('=')
 		}
 	}
 
@@ -161,6 +336,38 @@ func (t Tags) String() string {
 	return buf.String()
 }
 
+// sortedKeys returns the tag keys in the order specified by the given
+// TagSortMode.
+func (t Tags) sortedKeys(mode TagSortMode) []string {
+	keys := make([]string, len(t))
+	i := 0
+	for k := range t {
+		keys[i] = k
+		i++
+	}
+
+	switch mode {
+	case TagSortDefault:
+		if AlphabetizeTagMaps {
+			sort.Strings(keys)
+		}
+	case TagSortNone:
+	case TagSortAlphabetical:
+		sort.Strings(keys)
+	case TagSortServerFirst:
+		sort.Slice(keys, func(i, j int) bool {
+			iClient := strings.HasPrefix(keys[i], "+")
+			jClient := strings.HasPrefix(keys[j], "+")
+			if iClient != jClient {
+				return jClient
+			}
+			return keys[i] < keys[j]
+		})
+	}
+
+	return keys
+}
+
 // Prefix represents the prefix of a message, generally the user who sent it.
 type Prefix struct {
 	// Name will contain the nick of who sent the message, the
@@ -177,25 +384,71 @@ type Prefix struct {
 // ParsePrefix takes an identity string and parses it into an
 // identity struct. It will always return an Prefix struct and never
 // nil.
+//
+// Everything after the first @ is the Host. Of what remains, everything after
+// the first ! is the User and everything before it is the Name. A component
+// which is present but empty can't be told apart from a missing one, so
+// "nick!@host" and "nick@host" both result in an empty User and "nick!user@"
+// results in an empty Host.
 func ParsePrefix(line string) *Prefix {
 	// Start by creating an Prefix with nothing but the host
 	id := &Prefix{
 		Name: line,
 	}
 
-	uh := strings.SplitN(id.Name, "@", 2)
-	if len(uh) == 2 {
-		id.Name, id.Host = uh[0], uh[1]
+	// This is on the hot path for every message with a prefix, so the parts
+	// are found with Index rather than SplitN to avoid allocating.
+	if loc := strings.IndexByte(id.Name, '@'); loc != -1 {
+		id.Name, id.Host = id.Name[:loc], id.Name[loc+1:]
 	}
 
-	nu := strings.SplitN(id.Name, "!", 2)
-	if len(nu) == 2 {
-		id.Name, id.User = nu[0], nu[1]
+	if loc := strings.IndexByte(id.Name, '!'); loc != -1 {
+		id.Name, id.User = id.Name[:loc], id.Name[loc+1:]
 	}
 
 	return id
 }
 
+// NewPrefix creates a new Prefix from its parts. Any of them may be empty.
+func NewPrefix(nick, user, host string) *Prefix {
+	return &Prefix{
+		Name: nick,
+		User: user,
+		Host: host,
+	}
+}
+
+// WithNick returns a copy of this Prefix with the Name replaced. The original
+// is left untouched, so a Prefix can be shared between messages safely.
+func (p *Prefix) WithNick(nick string) *Prefix {
+	ret := p.withCopy()
+	ret.Name = nick
+	return ret
+}
+
+// WithUser returns a copy of this Prefix with the User replaced.
+func (p *Prefix) WithUser(user string) *Prefix {
+	ret := p.withCopy()
+	ret.User = user
+	return ret
+}
+
+// WithHost returns a copy of this Prefix with the Host replaced.
+func (p *Prefix) WithHost(host string) *Prefix {
+	ret := p.withCopy()
+	ret.Host = host
+	return ret
+}
+
+// withCopy is the same as Copy, but returns an empty Prefix rather than nil.
+func (p *Prefix) withCopy() *Prefix {
+	if p == nil {
+		return &Prefix{}
+	}
+
+	return p.Copy()
+}
+
 // Copy will create a new copy of an Prefix.
 func (p *Prefix) Copy() *Prefix {
 	if p == nil {
@@ -211,23 +464,70 @@ func (p *Prefix) Copy() *Prefix {
 
 // String ensures this is stringable.
 func (p *Prefix) String() string {
-	buf := &bytes.Buffer{}
-	buf.WriteString(p.Name)
+	return string(p.appendTo(nil))
+}
+
+// appendTo appends the serialized prefix to b.
+func (p *Prefix) appendTo(b []byte) []byte {
+	b = append(b, p.Name...)
 
 	if p.User != "" {
-		buf.WriteString("!")
-		buf.WriteString(p.User)
+		b = append(b, '!')
+		b = append(b, p.User...)
 	}
 
 	if p.Host != "" {
-		buf.WriteString("@")
-		buf.WriteString(p.Host)
+		b = append(b, '@')
+		b = append(b, p.Host...)
 	}
 
-	return buf.String()
+	return b
+}
+
+// Nick returns the nick of who sent the message. This is an alias for Name,
+// but makes the intent clearer for user prefixes.
+func (p *Prefix) Nick() string {
+	return p.Name
+}
+
+// NUH returns the full nick!user@host mask for a user prefix. For a server
+// prefix (which only has a Name), it simply returns the Name.
+func (p *Prefix) NUH() string {
+	if p.User == "" && p.Host == "" {
+		return p.Name
+	}
+
+	return p.String()
+}
+
+// IsServer returns true if this looks like a server prefix, which only has a
+// Name containing a '.'. Nicks can't contain a '.', so this won't match a user
+// prefix, even if it only has a nick.
+func (p *Prefix) IsServer() bool {
+	return p.User == "" && p.Host == "" && strings.Contains(p.Name, ".")
+}
+
+// Normalized returns a copy of this Prefix which can be used as a key when
+// tracking users. The Name is folded using the given CaseMapping and the Host
+// is lowered (ASCII only). The original Prefix is left untouched.
+func (p *Prefix) Normalized(mapping CaseMapping) *Prefix {
+	if p == nil {
+		return nil
+	}
+
+	return &Prefix{
+		Name: mapping.ToLower(p.Name),
+		User: p.User,
+		Host: CaseMappingASCII.ToLower(p.Host),
+	}
 }
 
 // Message represents a line parsed from the server.
+//
+// A Message is not safe for concurrent use if any goroutine modifies it. In
+// particular, Tags is a plain map, so modifying the tags while another
+// goroutine serializes the message is a data race. Use SnapshotTags, Copy or
+// Clone to hand a message off to another goroutine.
 type Message struct {
 	// Each message can have IRCv3 tags
 	Tags
@@ -238,9 +538,16 @@ type Message struct {
 	// Command is which command is being called.
 	Command string
 
-	// Params are all the arguments for the command.
+	// Params are all the arguments for the command. An explicitly empty
+	// trailing param (as in "TOPIC #chan :") is kept as an empty string so it
+	// can be told apart from a missing one. A nil and a zero-length Params
+	// are equivalent and both mean there are no params.
 	Params []string
 
+	// SortTags controls the order tags are written in when serializing this
+	// message.
+	SortTags TagSortMode
+
 	// Lemurian modification
 	//
 	// We should always return the originalTags string under the hood
@@ -257,19 +564,95 @@ func MustParseMessage(line string) *Message {
 	return m
 }
 
+// Raw builds a Message from a line which has already been formatted, such as
+// a command this package doesn't have a helper for. This allows it to be sent
+// the same way as any other Message. As the line is parsed with ParseMessage,
+// the same errors may be returned. Any trailing CRLF is ignored, but
+// ErrEmbeddedNewline is returned if there are any other newlines, as they
+// would otherwise allow sending more than one line.
+func Raw(line string) (*Message, error) {
+	if strings.ContainsAny(strings.TrimRight(line, "\r\n"), "\r\n") {
+		return nil, ErrEmbeddedNewline
+	}
+
+	return ParseMessage(line)
+}
+
+// MustRaw calls Raw and either returns the message or panics if an error is
+// returned.
+func MustRaw(line string) *Message {
+	m, err := Raw(line)
+	if err != nil {
+		panic(err.Error())
+	}
+	return m
+}
+
+// ParseAll parses every line in data, which may be separated by either CRLF or
+// LF. Empty lines are skipped. Lines which fail to parse are also skipped so
+// the rest can still be used, but the first error encountered is returned
+// along with the line number it was on.
+func ParseAll(data string) ([]*Message, error) {
+	var ret []*Message
+	var firstErr error
+
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" {
+			continue
+		}
+
+		m, err := ParseMessage(line)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("line %d: %w", i+1, err)
+			}
+			continue
+		}
+
+		ret = append(ret, m)
+	}
+
+	return ret, firstErr
+}
+
 // ParseMessage takes a message string (usually a whole line) and
 // parses it into a Message struct. This will return nil in the case
-// of invalid messages.
-func ParseMessage(line string) (*Message, error) { //nolint:funlen
+// of invalid messages. There is no limit on the number of params.
+//
+// A tag section or prefix which is not followed by a space at all results in
+// ErrMissingDataAfterTags or ErrMissingDataAfterPrefix respectively. If they
+// are followed by nothing but whitespace, ErrMissingCommand is returned, so a
+// Message with an empty Command is never produced.
+//
+// The tags and prefix are each separated from the rest of the message by the
+// first space after them. Tags are only parsed if the line starts with an '@',
+// and the tag section always ends at the first space, as spaces in tag values
+// must be escaped. Any extra spaces after that are skipped, so "@a=b   PING"
+// is parsed the same as "@a=b PING", unless ParseOptions.Strict is set, in
+// which case they result in ErrExtraSpaceAfterTags or
+// ErrExtraSpaceAfterPrefix.
+//
+// Runs of spaces between the command and params are treated as a single
+// space, as required by the IRCv3 message format, so empty middle params are
+// never produced. Only the trailing param can be empty.
+func ParseMessage(line string) (*Message, error) {
+	return ParseMessageOpts(line, ParseOptions{})
+}
+
+// ParseMessageOpts is the same as ParseMessage, but allows the parsing to be
+// customized with the given ParseOptions.
+func ParseMessageOpts(line string, opts ParseOptions) (*Message, error) { //nolint:funlen
 	// Trim the line and make sure we have data
 	line = strings.TrimRight(line, "\r\n")
 	if len(line) == 0 {
 		return nil, ErrZeroLengthMessage
 	}
 
-	c := &Message{
-		Tags:   Tags{},
-		Prefix: &Prefix{},
+	c := &Message{}
+
+	if !opts.SkipTags {
+		c.Tags = Tags{}
 	}
 
 	if line[0] == '@' {
@@ -278,12 +661,31 @@ func ParseMessage(line string) (*Message, error) { //nolint:funlen
 			return nil, ErrMissingDataAfterTags
 		}
 
-		c.originalTags = line[1:loc]
-		c.Tags = ParseTags(line[1:loc])
+		if !opts.SkipTags {
+			if opts.Strict && !validTagKeys(line[1:loc]) {
+				return nil, ErrInvalidTagKey
+			}
+
+			if opts.Strict && !validTagEscapes(line[1:loc]) {
+				return nil, ErrInvalidTagEscape
+			}
+
+			c.originalTags = line[1:loc]
+			c.Tags = ParseTags(line[1:loc])
+		}
+
 		line = line[loc+1:]
+
+		if strings.HasPrefix(line, " ") {
+			if opts.Strict {
+				return nil, ErrExtraSpaceAfterTags
+			}
+
+			line = strings.TrimLeft(line, " ")
+		}
 	}
 
-	if line[0] == ':' {
+	if len(line) > 0 && line[0] == ':' {
 		loc := strings.Index(line, " ")
 		if loc == -1 {
 			return nil, ErrMissingDataAfterPrefix
@@ -292,33 +694,78 @@ func ParseMessage(line string) (*Message, error) { //nolint:funlen
 		// Parse the identity, if there was one
 		c.Prefix = ParsePrefix(line[1:loc])
 		line = line[loc+1:]
+
+		if opts.Strict && strings.HasPrefix(line, " ") {
+			return nil, ErrExtraSpaceAfterPrefix
+		}
+	} else {
+		c.Prefix = &Prefix{}
 	}
 
 	// Split out the trailing then the rest of the args. Because
 	// we expect there to be at least one result as an arg (the
 	// command) we don't need to special case the trailing arg and
-	// can just attempt a split on " :"
-	split := strings.SplitN(line, " :", 2)
-	c.Params = strings.FieldsFunc(split[0], func(r rune) bool {
-		return r == ' '
-	})
+	// can just look for the first " :"
+	middle, trailing := line, ""
+	hasTrailing := false
+
+	if loc := strings.Index(line, " :"); loc != -1 {
+		middle, trailing = line[:loc], line[loc+2:]
+		hasTrailing = true
+	}
+
+	// Count the params before splitting, so they can be allocated in one go,
+	// and so a line with a huge number of params is rejected without
+	// allocating all of them. GreedyTrailing may join params back together,
+	// so that case is checked after splitting.
+	fields := countFields(middle)
+	if opts.MaxParams > 0 && (hasTrailing || !opts.GreedyTrailing) {
+		// The command is counted as a field, so it needs to be accounted
+		// for.
+		count := fields - 1
+		if hasTrailing {
+			count++
+		}
+
+		if count > opts.MaxParams {
+			return nil, ErrTooManyParams
+		}
+	}
 
 	// If there are no args, we need to bail because we need at
 	// least the command.
-	if len(c.Params) == 0 {
+	if fields == 0 {
 		return nil, ErrMissingCommand
 	}
 
+	c.Params = splitFields(middle, fields, hasTrailing)
+
 	// If we had a trailing arg, append it to the other args
-	if len(split) == 2 {
-		c.Params = append(c.Params, split[1])
+	if hasTrailing {
+		if opts.TrimParamWhitespace {
+			trailing = strings.TrimRight(trailing, " ")
+		}
+
+		c.Params = append(c.Params, trailing)
+	} else if opts.GreedyTrailing {
+		c.Params = greedyTrailing(middle, c.Params)
+	}
+
+	// Note that the command hasn't been split out yet, so it needs to be
+	// accounted for.
+	if opts.MaxParams > 0 && len(c.Params)-1 > opts.MaxParams {
+		return nil, ErrTooManyParams
 	}
 
 	// Because of how it's parsed, the Command will show up as the
 	// first arg.
-	c.Command = strings.ToUpper(c.Params[0])
+	c.Command = c.Params[0]
 	c.Params = c.Params[1:]
 
+	if !opts.PreserveCommandCase {
+		c.Command = strings.ToUpper(c.Command)
+	}
+
 	// If there are no params, set it to nil, to make writing tests and other
 	// things simpler.
 	if len(c.Params) == 0 {
@@ -328,6 +775,72 @@ func ParseMessage(line string) (*Message, error) { //nolint:funlen
 	return c, nil
 }
 
+// countFields counts the space-separated fields in s, the same way they are
+// split by ParseMessageOpts, without allocating them.
+func countFields(s string) int {
+	count := 0
+	inField := false
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == ' ' {
+			inField = false
+		} else if !inField {
+			inField = true
+			count++
+		}
+	}
+
+	return count
+}
+
+// splitFields splits s into the given number of space-separated fields, as
+// counted by countFields. If withTrailing is true, room is left for one more
+// param, so appending the trailing param doesn't need to grow the slice.
+func splitFields(s string, count int, withTrailing bool) []string {
+	capacity := count
+	if withTrailing {
+		capacity++
+	}
+
+	ret := make([]string, 0, capacity)
+	start := -1
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == ' ' {
+			if start != -1 {
+				ret = append(ret, s[start:i])
+				start = -1
+			}
+		} else if start == -1 {
+			start = i
+		}
+	}
+
+	if start != -1 {
+		ret = append(ret, s[start:])
+	}
+
+	return ret
+}
+
+// greedyTrailing joins any params after a command's fixed params back into a
+// single trailing param. raw is the part of the line the params (including
+// the command) were split from, used to keep the original spacing.
+func greedyTrailing(raw string, params []string) []string {
+	fixed, ok := greedyTrailingParams[strings.ToUpper(params[0])]
+	if !ok || len(params) <= fixed+2 {
+		return params
+	}
+
+	// Skip over the command and fixed params in the raw line.
+	for _, param := range params[:fixed+1] {
+		raw = strings.TrimLeft(raw, " ")
+		raw = raw[len(param):]
+	}
+
+	return append(params[:fixed+1], strings.TrimLeft(raw, " "))
+}
+
 // Param returns the i'th argument in the Message or an empty string
 // if the requested arg does not exist.
 func (m *Message) Param(i int) string {
@@ -347,7 +860,49 @@ func (m *Message) Trailing() string {
 	return m.Params[len(m.Params)-1]
 }
 
-// Copy will create a new copy of an message.
+// IsFromNick checks if this message was sent by the given nick, using mapping
+// to compare them. It returns false if there is no prefix.
+func (m *Message) IsFromNick(nick string, mapping CaseMapping) bool {
+	if m.Prefix == nil || m.Prefix.Name == "" {
+		return false
+	}
+
+	return NickEqual(m.Prefix.Nick(), nick, mapping)
+}
+
+// IsNumeric returns true if the Command is a numeric reply, which is made up of
+// exactly three ASCII digits.
+func (m *Message) IsNumeric() bool {
+	if len(m.Command) != 3 {
+		return false
+	}
+
+	for i := 0; i < len(m.Command); i++ {
+		if !isDigit(m.Command[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Numeric returns the Command as an integer if it is a numeric reply.
+func (m *Message) Numeric() (int, bool) {
+	if !m.IsNumeric() {
+		return 0, false
+	}
+
+	ret := 0
+	for i := 0; i < len(m.Command); i++ {
+		ret = ret*10 + int(m.Command[i]-'0')
+	}
+
+	return ret, true
+}
+
+// Copy will create a new copy of an message. Nothing is shared between the
+// two messages, so either can be modified freely, including from different
+// goroutines. If the copy will only be read, Clone is cheaper.
 func (m *Message) Copy() *Message {
 	// Create a new message
 	newMessage := &Message{}
@@ -372,54 +927,95 @@ func (m *Message) Copy() *Message {
 	return newMessage
 }
 
-// String ensures this is stringable.
-func (m *Message) String() string {
+// Clone creates a cheaper copy of a message for passing to read-only
+// handlers. The Prefix and Params are copied, but unlike Copy, the Tags map is
+// shared with the original message. Neither message's Tags may be modified
+// while the other is in use; use Copy if the tags need to change. SnapshotTags
+// can be used to give the clone its own tags later on.
+func (m *Message) Clone() *Message {
+	newMessage := &Message{}
+	*newMessage = *m
 
-	tagString := m.Tags.String()
-	// If this IRC message struct was instantiated by parsing, return the exact same message.
-	//
-	// This prevents tag order from randomly changing between multiple parsings of the same message.
-	if reflect.DeepEqual(ParseTags(m.originalTags), m.Tags) {
-		tagString = m.originalTags
+	newMessage.Prefix = m.Prefix.Copy()
+
+	if len(m.Params) > 0 {
+		newMessage.Params = append(make([]string, 0, len(m.Params)), m.Params...)
+	} else {
+		newMessage.Params = nil
 	}
 
-	buf := &bytes.Buffer{}
+	return newMessage
+}
+
+// String ensures this is stringable. Only the last param may be empty, contain
+// a space, or start with a ':' as it will be written as a trailing param.
+// Messages which break this rule cannot be represented on the wire.
+func (m *Message) String() string {
+	return string(m.appendLine(nil))
+}
+
+// AppendTo appends the message to b as it would be sent on the wire, including
+// the trailing CRLF, and returns the extended buffer. This allows a buffer to
+// be reused when writing many messages. The same rules as String apply.
+func (m *Message) AppendTo(b []byte) []byte {
+	return append(m.appendLine(b), '\r', '\n')
+}
 
+// appendLine appends the message to b without the trailing CRLF.
+func (m *Message) appendLine(b []byte) []byte {
 	// Write any IRCv3 tags if they exist in the message
 	if len(m.Tags) > 0 {
-		buf.WriteByte('@')
-		buf.WriteString(tagString)
-		buf.WriteByte(' ')
+		tagString := m.originalTags
+
+		// If this IRC message struct was instantiated by parsing, use the
+		// exact same tags. This prevents tag order from randomly changing
+		// between multiple parsings of the same message.
+		if m.originalTags == "" || m.SortTags != TagSortDefault || !reflect.DeepEqual(ParseTags(m.originalTags), m.Tags) {
+			tagString = m.Tags.stringSorted(m.SortTags, m.originalTags)
+		}
+
+		b = append(b, '@')
+		b = append(b, tagString...)
+		b = append(b, ' ')
 	}
 
-	// Add the prefix if we have one
-	if m.Prefix != nil && m.Prefix.Name != "" {
-		buf.WriteByte(':')
-		buf.WriteString(m.Prefix.String())
-		buf.WriteByte(' ')
+	// Add the prefix if we have one. Any non-empty component needs to be
+	// written, otherwise a prefix like "@host" would be lost when the message
+	// is parsed again.
+	if m.Prefix != nil && (m.Prefix.Name != "" || m.Prefix.User != "" || m.Prefix.Host != "") {
+		b = append(b, ':')
+		b = m.Prefix.appendTo(b)
+		b = append(b, ' ')
 	}
 
 	// Add the command since we know we'll always have one
-	buf.WriteString(m.Command)
+	b = append(b, m.Command...)
 
 	if len(m.Params) > 0 {
 		args := m.Params[:len(m.Params)-1]
 		trailing := m.Params[len(m.Params)-1]
 
-		if len(args) > 0 {
-			buf.WriteByte(' ')
-			buf.WriteString(strings.Join(args, " "))
+		for _, arg := range args {
+			b = append(b, ' ')
+			b = append(b, arg...)
 		}
 
 		// If trailing is zero-length, contains a space or starts with
 		// a : we need to actually specify that it's trailing.
-		if len(trailing) == 0 || strings.ContainsRune(trailing, ' ') || trailing[0] == ':' {
-			buf.WriteString(" :")
+		if needsTrailingMarker(trailing) {
+			b = append(b, ' ', ':')
 		} else {
-			buf.WriteString(" ")
+			b = append(b, ' ')
 		}
-		buf.WriteString(trailing)
+		b = append(b, trailing...)
 	}
 
-	return buf.String()
+	return b
+}
+
+// needsTrailingMarker returns true if the given param can only be represented
+// as a trailing param, prefixed with a ':'. Note that this is only possible for
+// the last param in a message.
+func needsTrailingMarker(param string) bool {
+	return len(param) == 0 || strings.ContainsRune(param, ' ') || param[0] == ':'
 }
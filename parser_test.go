@@ -31,12 +31,67 @@ func BenchmarkParseMessage(b *testing.B) {
 	}
 }
 
+const tagHeavyLine = "@time=2020-01-01T00:00:00.000Z;msgid=abc123;account=lemuria;batch=xyz;+draft/reply=def;+example.com/foo=bar :lemuria!lemuria@lemuria.ph PRIVMSG #lemuria :meow"
+
+func BenchmarkParseMessageTags(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = irc.ParseMessageOpts(tagHeavyLine, irc.ParseOptions{})
+	}
+}
+
+func BenchmarkParseMessageTooManyParams(b *testing.B) {
+	line := "CMD" + strings.Repeat(" a", 10000)
+	opts := irc.ParseOptions{MaxParams: irc.RFC1459MaxParams}
+
+	for i := 0; i < b.N; i++ {
+		_, _ = irc.ParseMessageOpts(line, opts)
+	}
+}
+
+func BenchmarkParseMessageNoTags(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		irc.MustParseMessage(":nick!user@host PRIVMSG #channel :some message")
+	}
+}
+
+func BenchmarkParseMessageSkipTags(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = irc.ParseMessageOpts(tagHeavyLine, irc.ParseOptions{SkipTags: true})
+	}
+}
+
 func BenchmarkStringMessage(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_ = newM.String()
 	}
 }
 
+var noTagsM = irc.MustParseMessage(":lemuria!lemuria@lemuria.ph PRIVMSG #lemuria meow")
+
+func BenchmarkStringMessageNoTags(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = noTagsM.String()
+	}
+}
+
+var fanOutM = irc.MustParseMessage("@time=2020-01-01T00:00:00.000Z;msgid=abc123;account=lemuria;+draft/reply=xyz :lemuria!lemuria@lemuria.ph PRIVMSG #lemuria :meow")
+
+func BenchmarkCopyFanOut(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 10; j++ {
+			_ = fanOutM.Copy()
+		}
+	}
+}
+
+func BenchmarkCloneFanOut(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 10; j++ {
+			_ = fanOutM.Clone()
+		}
+	}
+}
+
 var letterRunes = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
 
 func RandStringRunes(n int) string {
@@ -47,6 +102,19 @@ func RandStringRunes(n int) string {
     return string(b)
 }
 
+func BenchmarkStringMessageBytes(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = []byte(newM.String() + "\r\n")
+	}
+}
+
+func BenchmarkAppendToMessage(b *testing.B) {
+	buf := make([]byte, 0, 512)
+	for i := 0; i < b.N; i++ {
+		buf = newM.AppendTo(buf[:0])
+	}
+}
+
 func BenchmarkStringMessageAlphabetized(b *testing.B) {
 	b.StopTimer() // we need to warm up the messages for sorting first
 	irc.AlphabetizeTagMaps = true
@@ -89,6 +157,30 @@ func TestParseMessage(t *testing.T) {
 			Input: " :",
 			Err:   irc.ErrMissingCommand,
 		},
+		{
+			Input: ":prefix ",
+			Err:   irc.ErrMissingCommand,
+		},
+		{
+			Input: ":prefix   ",
+			Err:   irc.ErrMissingCommand,
+		},
+		{
+			Input: "@tag ",
+			Err:   irc.ErrMissingCommand,
+		},
+		{
+			Input: "@tag :prefix",
+			Err:   irc.ErrMissingDataAfterPrefix,
+		},
+		{
+			Input: "@tag :prefix ",
+			Err:   irc.ErrMissingCommand,
+		},
+		{
+			Input: "@tag :prefix \r\n",
+			Err:   irc.ErrMissingCommand,
+		},
 		{
 			Input: "PING :asdf",
 		},
@@ -118,6 +210,489 @@ func TestMustParseMessage(t *testing.T) {
 	}, "Got unexpected panic")
 }
 
+func TestRaw(t *testing.T) {
+	t.Parallel()
+
+	m, err := irc.Raw("CUSTOMCMD arg1 arg2 :some trailing text\r\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "CUSTOMCMD", m.Command)
+	assert.Equal(t, []string{"arg1", "arg2", "some trailing text"}, m.Params)
+	assert.Equal(t, "CUSTOMCMD arg1 arg2 :some trailing text", m.String())
+
+	_, err = irc.Raw("")
+	assert.Equal(t, irc.ErrZeroLengthMessage, err)
+
+	_, err = irc.Raw("PRIVMSG #chan :hello\r\nQUIT :injected")
+	assert.Equal(t, irc.ErrEmbeddedNewline, err)
+
+	assert.Panics(t, func() {
+		irc.MustRaw("PRIVMSG #chan :hello\nQUIT")
+	})
+
+	assert.NotPanics(t, func() {
+		irc.MustRaw("PING :asdf")
+	})
+}
+
+func TestParseAll(t *testing.T) {
+	t.Parallel()
+
+	msgs, err := irc.ParseAll("PING :1\r\n:nick!user@host PRIVMSG #chan :hello\n\r\n\nPING :2\r\n")
+	assert.NoError(t, err)
+	if assert.Len(t, msgs, 3) {
+		assert.Equal(t, "1", msgs[0].Trailing())
+		assert.Equal(t, "hello", msgs[1].Trailing())
+		assert.Equal(t, "2", msgs[2].Trailing())
+	}
+
+	msgs, err = irc.ParseAll("")
+	assert.NoError(t, err)
+	assert.Empty(t, msgs)
+
+	// Invalid lines should be skipped, but reported
+	msgs, err = irc.ParseAll("PING :1\r\n:prefix\r\n@tags\r\nPING :2")
+	assert.ErrorIs(t, err, irc.ErrMissingDataAfterPrefix)
+	assert.Contains(t, err.Error(), "line 2")
+	assert.Len(t, msgs, 2)
+}
+
+func TestParseMessageOptsMaxParams(t *testing.T) {
+	t.Parallel()
+
+	opts := irc.ParseOptions{MaxParams: irc.RFC1459MaxParams}
+
+	// 14 middle params and a trailing param is right at the limit.
+	line := "CMD" + strings.Repeat(" a", 14) + " :trailing"
+	m, err := irc.ParseMessageOpts(line, opts)
+	assert.NoError(t, err)
+	assert.Len(t, m.Params, 15)
+
+	m, err = irc.ParseMessageOpts("CMD"+strings.Repeat(" a", 15), opts)
+	assert.NoError(t, err)
+	assert.Len(t, m.Params, 15)
+
+	m, err = irc.ParseMessageOpts(line+" extra", opts)
+	assert.NoError(t, err, "Extra data in trailing shouldn't be counted")
+	assert.Len(t, m.Params, 15)
+
+	// One more pushes it over.
+	m, err = irc.ParseMessageOpts("CMD"+strings.Repeat(" a", 15)+" :trailing", opts)
+	assert.Equal(t, irc.ErrTooManyParams, err)
+	assert.Nil(t, m)
+
+	m, err = irc.ParseMessageOpts("CMD"+strings.Repeat(" a", 16), opts)
+	assert.Equal(t, irc.ErrTooManyParams, err)
+	assert.Nil(t, m)
+
+	// Extra spaces between params shouldn't be counted.
+	m, err = irc.ParseMessageOpts("CMD"+strings.Repeat("  a", 14)+"   :trailing", opts)
+	assert.NoError(t, err)
+	assert.Len(t, m.Params, 15)
+
+	// GreedyTrailing joins the params before they are counted.
+	greedyOpts := irc.ParseOptions{MaxParams: 2, GreedyTrailing: true}
+	m, err = irc.ParseMessageOpts("PRIVMSG #chan hello there world", greedyOpts)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"#chan", "hello there world"}, m.Params)
+
+	_, err = irc.ParseMessageOpts("MODE #chan +oo a b", greedyOpts)
+	assert.Equal(t, irc.ErrTooManyParams, err)
+
+	// ParseMessage has no limit.
+	m, err = irc.ParseMessage("CMD" + strings.Repeat(" a", 1000))
+	assert.NoError(t, err)
+	assert.Len(t, m.Params, 1000)
+}
+
+func TestParseMessageOptsPreserveCommandCase(t *testing.T) {
+	t.Parallel()
+
+	line := ":nick!user@host privmsg #chan :hello world"
+
+	m, err := irc.ParseMessageOpts(line, irc.ParseOptions{PreserveCommandCase: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "privmsg", m.Command)
+	assert.Equal(t, []string{"#chan", "hello world"}, m.Params)
+	assert.Equal(t, line, m.String())
+
+	m, err = irc.ParseMessageOpts(line, irc.ParseOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "PRIVMSG", m.Command)
+
+	// Options which don't mention the command mustn't change it either.
+	m, err = irc.ParseMessageOpts(line, irc.ParseOptions{MaxParams: irc.RFC1459MaxParams})
+	assert.NoError(t, err)
+	assert.Equal(t, "PRIVMSG", m.Command)
+}
+
+func TestParseMessageOptsZeroValue(t *testing.T) {
+	t.Parallel()
+
+	for _, line := range []string{
+		":nick!user@host privmsg #chan :hello world",
+		"@a=b;c :irc.example.com 001 nick :Welcome",
+		"ping :12345",
+		"NOTICE * :*** Looking up your hostname",
+	} {
+		expected, err := irc.ParseMessage(line)
+		assert.NoError(t, err)
+
+		m, err := irc.ParseMessageOpts(line, irc.ParseOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, m, "Message didn't match for %q", line)
+	}
+}
+
+func TestParseMessageOptsGreedyTrailing(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input  string
+		Normal []string
+		Greedy []string
+	}{
+		{
+			Input:  "PRIVMSG #chan hello world",
+			Normal: []string{"#chan", "hello", "world"},
+			Greedy: []string{"#chan", "hello world"},
+		},
+		{
+			// Spacing inside the text should be kept
+			Input:  ":nick!user@host privmsg  #chan  hello   world",
+			Normal: []string{"#chan", "hello", "world"},
+			Greedy: []string{"#chan", "hello   world"},
+		},
+		{
+			Input:  "KICK #chan nick you were warned",
+			Normal: []string{"#chan", "nick", "you", "were", "warned"},
+			Greedy: []string{"#chan", "nick", "you were warned"},
+		},
+		{
+			Input:  "QUIT gone to lunch",
+			Normal: []string{"gone", "to", "lunch"},
+			Greedy: []string{"gone to lunch"},
+		},
+		{
+			// A colon always takes priority
+			Input:  "PRIVMSG #chan a :b c",
+			Normal: []string{"#chan", "a", "b c"},
+			Greedy: []string{"#chan", "a", "b c"},
+		},
+		{
+			// Nothing to join
+			Input:  "PRIVMSG #chan hello",
+			Normal: []string{"#chan", "hello"},
+			Greedy: []string{"#chan", "hello"},
+		},
+		{
+			// Unknown commands are left alone
+			Input:  "MODE #chan +o nick",
+			Normal: []string{"#chan", "+o", "nick"},
+			Greedy: []string{"#chan", "+o", "nick"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		m, err := irc.ParseMessageOpts(testCase.Input, irc.ParseOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.Normal, m.Params, "Normal params didn't match for %q", testCase.Input)
+
+		m, err = irc.ParseMessageOpts(testCase.Input, irc.ParseOptions{GreedyTrailing: true})
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.Greedy, m.Params, "Greedy params didn't match for %q", testCase.Input)
+	}
+}
+
+func TestParseMessageSpacing(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input     string
+		Expect    *irc.Message
+		StrictErr error
+	}{
+		{
+			Input: "@a=b;c=d PRIVMSG #chan :hi",
+			Expect: &irc.Message{
+				Tags:    irc.Tags{"a": "b", "c": "d"},
+				Prefix:  &irc.Prefix{},
+				Command: "PRIVMSG",
+				Params:  []string{"#chan", "hi"},
+			},
+		},
+		{
+			Input: "@a=b;c=d :nick!user@host PRIVMSG #chan :hi",
+			Expect: &irc.Message{
+				Tags:    irc.Tags{"a": "b", "c": "d"},
+				Prefix:  &irc.Prefix{Name: "nick", User: "user", Host: "host"},
+				Command: "PRIVMSG",
+				Params:  []string{"#chan", "hi"},
+			},
+		},
+		{
+			Input: "@a=b;c=d   PRIVMSG #chan :hi",
+			Expect: &irc.Message{
+				Tags:    irc.Tags{"a": "b", "c": "d"},
+				Prefix:  &irc.Prefix{},
+				Command: "PRIVMSG",
+				Params:  []string{"#chan", "hi"},
+			},
+			StrictErr: irc.ErrExtraSpaceAfterTags,
+		},
+		{
+			Input: "@a=b;c=d  :nick!user@host PRIVMSG #chan :hi",
+			Expect: &irc.Message{
+				Tags:    irc.Tags{"a": "b", "c": "d"},
+				Prefix:  &irc.Prefix{Name: "nick", User: "user", Host: "host"},
+				Command: "PRIVMSG",
+				Params:  []string{"#chan", "hi"},
+			},
+			StrictErr: irc.ErrExtraSpaceAfterTags,
+		},
+		{
+			Input: "@a=b :nick!user@host  PRIVMSG #chan :hi",
+			Expect: &irc.Message{
+				Tags:    irc.Tags{"a": "b"},
+				Prefix:  &irc.Prefix{Name: "nick", User: "user", Host: "host"},
+				Command: "PRIVMSG",
+				Params:  []string{"#chan", "hi"},
+			},
+			StrictErr: irc.ErrExtraSpaceAfterPrefix,
+		},
+	}
+
+	for _, testCase := range testCases {
+		m, err := irc.ParseMessage(testCase.Input)
+		if assert.NoError(t, err, "Lenient parsing failed for %q", testCase.Input) {
+			assert.Equal(t, testCase.Expect.Tags, m.Tags, "Tags didn't match for %q", testCase.Input)
+			assert.Equal(t, testCase.Expect.Prefix, m.Prefix, "Prefix didn't match for %q", testCase.Input)
+			assert.Equal(t, testCase.Expect.Command, m.Command, "Command didn't match for %q", testCase.Input)
+			assert.Equal(t, testCase.Expect.Params, m.Params, "Params didn't match for %q", testCase.Input)
+		}
+
+		m, err = irc.ParseMessageOpts(testCase.Input, irc.ParseOptions{Strict: true})
+		assert.Equal(t, testCase.StrictErr, err, "Strict error didn't match for %q", testCase.Input)
+		if testCase.StrictErr != nil {
+			assert.Nil(t, m)
+		}
+	}
+}
+
+func TestParseMessageSpacesAfterTags(t *testing.T) {
+	t.Parallel()
+
+	for spaces := 1; spaces <= 3; spaces++ {
+		for _, rest := range []string{"PRIVMSG #chan :hi", ":nick!user@host PRIVMSG #chan :hi"} {
+			input := "@a=b;c=d" + strings.Repeat(" ", spaces) + rest
+
+			m, err := irc.ParseMessage(input)
+			if assert.NoError(t, err, "Lenient parsing failed for %q", input) {
+				assert.Equal(t, irc.Tags{"a": "b", "c": "d"}, m.Tags, "Tags didn't match for %q", input)
+				assert.Equal(t, "PRIVMSG", m.Command, "Command didn't match for %q", input)
+				assert.Equal(t, []string{"#chan", "hi"}, m.Params, "Params didn't match for %q", input)
+			}
+
+			m, err = irc.ParseMessageOpts(input, irc.ParseOptions{Strict: true})
+			if spaces == 1 {
+				assert.NoError(t, err, "Strict parsing failed for %q", input)
+				assert.NotNil(t, m)
+			} else {
+				assert.Equal(t, irc.ErrExtraSpaceAfterTags, err, "Strict error didn't match for %q", input)
+				assert.Nil(t, m)
+			}
+		}
+	}
+
+	// Tags followed only by spaces have no command
+	_, err := irc.ParseMessage("@a=b   ")
+	assert.Equal(t, irc.ErrMissingCommand, err)
+
+	_, err = irc.ParseMessageOpts("@a=b   ", irc.ParseOptions{Strict: true})
+	assert.Equal(t, irc.ErrExtraSpaceAfterTags, err)
+
+	// Escaped spaces in values don't end the tag section
+	m := irc.MustParseMessage(`@a=b\sc  PING`)
+	assert.Equal(t, "b c", m.Tags["a"])
+	assert.Equal(t, "PING", m.Command)
+}
+
+func TestParseMessageStrictTagEscapes(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input   string
+		Lenient string
+		Valid   bool
+	}{
+		{
+			Input:   `@a=\:\s\\\r\n PING`,
+			Lenient: "; \\\r\n",
+			Valid:   true,
+		},
+		{
+			// Dangling backslash at the end of the tags
+			Input:   `@a=b\ PING`,
+			Lenient: "b",
+		},
+		{
+			// Dangling backslash at the end of a value
+			Input:   `@a=b\;c=d PING`,
+			Lenient: "b",
+		},
+		{
+			Input:   `@a=\b PING`,
+			Lenient: "b",
+		},
+		{
+			Input:   `@a=\x PING`,
+			Lenient: "x",
+		},
+	}
+
+	for _, testCase := range testCases {
+		m, err := irc.ParseMessage(testCase.Input)
+		if assert.NoError(t, err, "Lenient parsing failed for %q", testCase.Input) {
+			assert.Equal(t, testCase.Lenient, m.Tags["a"], "Lenient value didn't match for %q", testCase.Input)
+		}
+
+		m, err = irc.ParseMessageOpts(testCase.Input, irc.ParseOptions{Strict: true})
+		if testCase.Valid {
+			assert.NoError(t, err, "Strict parsing failed for %q", testCase.Input)
+			assert.NotNil(t, m)
+		} else {
+			assert.Equal(t, irc.ErrInvalidTagEscape, err, "Strict error didn't match for %q", testCase.Input)
+			assert.Nil(t, m)
+		}
+	}
+}
+
+func TestParseMessageStrictTagKeys(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input string
+		Key   string
+		Valid bool
+	}{
+		{
+			Input: "@msgid=abc PING",
+			Key:   "msgid",
+			Valid: true,
+		},
+		{
+			Input: "@+example.com/foo-bar=abc;a PING",
+			Key:   "+example.com/foo-bar",
+			Valid: true,
+		},
+		{
+			Input: "@caf\u00e9=abc PING",
+			Key:   "caf\u00e9",
+		},
+		{
+			// The value is fine, but the second key isn't
+			Input: "@a=\u00e9;clé PING",
+			Key:   "clé",
+		},
+	}
+
+	for _, testCase := range testCases {
+		m, err := irc.ParseMessage(testCase.Input)
+		if assert.NoError(t, err, "Lenient parsing failed for %q", testCase.Input) {
+			assert.True(t, m.Tags.IsSet(testCase.Key), "Lenient key missing for %q", testCase.Input)
+		}
+
+		m, err = irc.ParseMessageOpts(testCase.Input, irc.ParseOptions{Strict: true})
+		if testCase.Valid {
+			assert.NoError(t, err, "Strict parsing failed for %q", testCase.Input)
+			assert.NotNil(t, m)
+		} else {
+			assert.Equal(t, irc.ErrInvalidTagKey, err, "Strict error didn't match for %q", testCase.Input)
+			assert.Nil(t, m)
+		}
+	}
+
+	m, err := irc.ParseMessageOpts("@a=é PING", irc.ParseOptions{Strict: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "é", m.Tags["a"])
+}
+
+func TestParseMessageParamSpacing(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input  string
+		Params []string
+	}{
+		{
+			Input:  "CMD a  b",
+			Params: []string{"a", "b"},
+		},
+		{
+			Input:  "CMD a   b   c",
+			Params: []string{"a", "b", "c"},
+		},
+		{
+			Input:  "CMD  a b  :trailing  text ",
+			Params: []string{"a", "b", "trailing  text "},
+		},
+		{
+			Input:  "CMD a  :",
+			Params: []string{"a", ""},
+		},
+		{
+			Input:  "CMD a b   ",
+			Params: []string{"a", "b"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		m, err := irc.ParseMessage(testCase.Input)
+		if assert.NoError(t, err, "Failed to parse %q", testCase.Input) {
+			assert.Equal(t, "CMD", m.Command)
+			assert.Equal(t, testCase.Params, m.Params, "Params didn't match for %q", testCase.Input)
+		}
+	}
+}
+
+func TestParseMessageOptsTrimParamWhitespace(t *testing.T) {
+	t.Parallel()
+
+	line := ":irc.example.com 372 nick :-  message   of the day   "
+
+	m, err := irc.ParseMessageOpts(line, irc.ParseOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"nick", "-  message   of the day   "}, m.Params)
+
+	m, err = irc.ParseMessageOpts(line, irc.ParseOptions{TrimParamWhitespace: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"nick", "-  message   of the day"}, m.Params)
+
+	// An all-space trailing param is still kept, just empty.
+	m, err = irc.ParseMessageOpts("TOPIC #chan :   ", irc.ParseOptions{TrimParamWhitespace: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"#chan", ""}, m.Params)
+}
+
+func TestParseMessageOptsSkipTags(t *testing.T) {
+	t.Parallel()
+
+	m, err := irc.ParseMessageOpts("@a=b;c=d :nick!user@host PRIVMSG #chan :hello world", irc.ParseOptions{SkipTags: true})
+	assert.NoError(t, err)
+	assert.Nil(t, m.Tags)
+	assert.Equal(t, "nick", m.Prefix.Name)
+	assert.Equal(t, "PRIVMSG", m.Command)
+	assert.Equal(t, []string{"#chan", "hello world"}, m.Params)
+	assert.Equal(t, ":nick!user@host PRIVMSG #chan :hello world", m.String())
+
+	m, err = irc.ParseMessageOpts("@a=b;c=d PING", irc.ParseOptions{SkipTags: true})
+	assert.NoError(t, err)
+	assert.Nil(t, m.Tags)
+	assert.Equal(t, "PING", m.Command)
+
+	_, err = irc.ParseMessageOpts("@a=b;c=d", irc.ParseOptions{SkipTags: true})
+	assert.Equal(t, irc.ErrMissingDataAfterTags, err)
+}
+
 func TestMessageParam(t *testing.T) {
 	t.Parallel()
 
@@ -137,6 +712,92 @@ func TestMessageTrailing(t *testing.T) {
 	assert.Equal(t, "", m.Trailing())
 }
 
+func TestMessageNumeric(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Command   string
+		IsNumeric bool
+		Numeric   int
+	}{
+		{Command: "001", IsNumeric: true, Numeric: 1},
+		{Command: "433", IsNumeric: true, Numeric: 433},
+		{Command: "42", IsNumeric: false},
+		{Command: "1", IsNumeric: false},
+		{Command: "1a2", IsNumeric: false},
+		{Command: "0001", IsNumeric: false},
+		{Command: "PRIVMSG", IsNumeric: false},
+	}
+
+	for _, testCase := range testCases {
+		m := &irc.Message{Command: testCase.Command}
+		assert.Equal(t, testCase.IsNumeric, m.IsNumeric(), "IsNumeric didn't match for %q", testCase.Command)
+
+		numeric, ok := m.Numeric()
+		assert.Equal(t, testCase.IsNumeric, ok, "Numeric ok didn't match for %q", testCase.Command)
+		assert.Equal(t, testCase.Numeric, numeric, "Numeric didn't match for %q", testCase.Command)
+	}
+}
+
+func TestMessageClone(t *testing.T) {
+	t.Parallel()
+
+	m := irc.MustParseMessage("@tag=val :user@host PING :helloworld")
+
+	c := m.Clone()
+	assert.EqualValues(t, m, c, "Cloned values are not equal")
+
+	// The prefix and params should be safe to modify
+	c.Prefix.Name += "junk"
+	c.Params[0] += "junk"
+	assert.Equal(t, "user", m.Prefix.Name)
+	assert.Equal(t, "helloworld", m.Params[0])
+
+	// But the tags are shared
+	c.Tags["tag"] = "changed"
+	assert.Equal(t, "changed", m.Tags["tag"])
+
+	m = irc.MustParseMessage("PING")
+	m.Prefix = nil
+	c = m.Clone()
+	assert.Nil(t, c.Prefix)
+	assert.Nil(t, c.Params)
+}
+
+func TestMessageAppendTo(t *testing.T) {
+	t.Parallel()
+
+	for _, line := range []string{
+		"PING",
+		"PING :hello world",
+		"@b=2;a=1 :nick!user@host PRIVMSG #chan :hello world",
+		":irc.example.com 005 nick CHANTYPES=# :are supported by this server",
+	} {
+		m := irc.MustParseMessage(line)
+
+		buf := []byte("existing")
+		buf = m.AppendTo(buf)
+		assert.Equal(t, "existing"+m.String()+"\r\n", string(buf))
+		assert.Equal(t, line, m.String())
+	}
+}
+
+func TestMessageIsFromNick(t *testing.T) {
+	t.Parallel()
+
+	m := irc.MustParseMessage(":Lemuria[m]!user@host PRIVMSG #chan :hello")
+	assert.True(t, m.IsFromNick("lemuria{M}", irc.CaseMappingRFC1459))
+	assert.True(t, m.IsFromNick("LEMURIA[M]", irc.CaseMappingASCII))
+	assert.False(t, m.IsFromNick("lemuria{m}", irc.CaseMappingASCII))
+	assert.False(t, m.IsFromNick("other", irc.CaseMappingRFC1459))
+
+	m = irc.MustParseMessage("PRIVMSG #chan :hello")
+	assert.False(t, m.IsFromNick("", irc.CaseMappingRFC1459))
+
+	m.Prefix = nil
+	assert.False(t, m.IsFromNick("lemuria", irc.CaseMappingRFC1459))
+}
+
 func TestMessageCopy(t *testing.T) {
 	t.Parallel()
 
@@ -178,6 +839,170 @@ func TestMessageCopy(t *testing.T) {
 	assert.Nil(t, c.Params, "Expected nil for empty params")
 }
 
+func TestEmptyTrailing(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Params []string
+		Line   string
+		Parsed []string
+	}{
+		{
+			Params: nil,
+			Line:   "TOPIC",
+			Parsed: nil,
+		},
+		{
+			Params: []string{},
+			Line:   "TOPIC",
+			Parsed: nil,
+		},
+		{
+			Params: []string{""},
+			Line:   "TOPIC :",
+			Parsed: []string{""},
+		},
+		{
+			Params: []string{"#chan", ""},
+			Line:   "TOPIC #chan :",
+			Parsed: []string{"#chan", ""},
+		},
+	}
+
+	for _, testCase := range testCases {
+		m := &irc.Message{Command: "TOPIC", Params: testCase.Params}
+		assert.Equal(t, testCase.Line, m.String())
+
+		parsed := irc.MustParseMessage(testCase.Line)
+		assert.Equal(t, testCase.Parsed, parsed.Params, "Params didn't match for %q", testCase.Line)
+	}
+}
+
+func TestParsePrefix(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input  string
+		Expect irc.Prefix
+	}{
+		{
+			Input:  "nick",
+			Expect: irc.Prefix{Name: "nick"},
+		},
+		{
+			Input:  "nick!user@host",
+			Expect: irc.Prefix{Name: "nick", User: "user", Host: "host"},
+		},
+		{
+			Input:  "nick!@host",
+			Expect: irc.Prefix{Name: "nick", User: "", Host: "host"},
+		},
+		{
+			Input:  "nick!user@",
+			Expect: irc.Prefix{Name: "nick", User: "user", Host: ""},
+		},
+		{
+			Input:  "nick@host",
+			Expect: irc.Prefix{Name: "nick", Host: "host"},
+		},
+		{
+			Input:  "nick!user",
+			Expect: irc.Prefix{Name: "nick", User: "user"},
+		},
+		{
+			Input:  "nick!@",
+			Expect: irc.Prefix{Name: "nick"},
+		},
+		{
+			Input:  "nick@host!user",
+			Expect: irc.Prefix{Name: "nick", Host: "host!user"},
+		},
+		{
+			Input:  "nick!user@2001:db8::1",
+			Expect: irc.Prefix{Name: "nick", User: "user", Host: "2001:db8::1"},
+		},
+		{
+			Input:  "nick!user@[2001:db8::1]",
+			Expect: irc.Prefix{Name: "nick", User: "user", Host: "[2001:db8::1]"},
+		},
+		{
+			// Some servers prefix IPv6 hosts with a 0 so they don't
+			// start with a colon.
+			Input:  "nick!user@0::1",
+			Expect: irc.Prefix{Name: "nick", User: "user", Host: "0::1"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.Expect, *irc.ParsePrefix(testCase.Input), "Prefix didn't match for %q", testCase.Input)
+	}
+
+	// Colons in the host shouldn't affect parsing of the rest of the message.
+	m := irc.MustParseMessage(":nick!user@2001:db8::1 PRIVMSG #chan :hello world")
+	assert.Equal(t, "2001:db8::1", m.Prefix.Host)
+	assert.Equal(t, "PRIVMSG", m.Command)
+	assert.Equal(t, []string{"#chan", "hello world"}, m.Params)
+	assert.Equal(t, ":nick!user@2001:db8::1 PRIVMSG #chan :hello world", m.String())
+}
+
+func TestPrefixNickNUH(t *testing.T) {
+	t.Parallel()
+
+	p := irc.ParsePrefix("nick!user@host")
+	assert.Equal(t, "nick", p.Nick())
+	assert.Equal(t, "nick!user@host", p.NUH())
+
+	p = irc.ParsePrefix("irc.example.com")
+	assert.Equal(t, "irc.example.com", p.Nick())
+	assert.Equal(t, "irc.example.com", p.NUH())
+}
+
+func TestPrefixWith(t *testing.T) {
+	t.Parallel()
+
+	p := irc.NewPrefix("nick", "user", "host")
+	assert.Equal(t, "nick!user@host", p.String())
+
+	assert.Equal(t, "other!user@host", p.WithNick("other").String())
+	assert.Equal(t, "nick!other@host", p.WithUser("other").String())
+	assert.Equal(t, "nick!user@other", p.WithHost("other").String())
+	assert.Equal(t, "a!b@c", p.WithNick("a").WithUser("b").WithHost("c").String())
+
+	// The original should never change
+	assert.Equal(t, &irc.Prefix{Name: "nick", User: "user", Host: "host"}, p)
+
+	var empty *irc.Prefix
+	assert.Equal(t, &irc.Prefix{Name: "nick"}, empty.WithNick("nick"))
+}
+
+func TestPrefixIsServer(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, irc.ParsePrefix("irc.example.com").IsServer())
+	assert.False(t, irc.ParsePrefix("nick").IsServer())
+	assert.False(t, irc.ParsePrefix("nick!user@host.example.com").IsServer())
+	assert.False(t, irc.ParsePrefix("").IsServer())
+}
+
+func TestPrefixNormalized(t *testing.T) {
+	t.Parallel()
+
+	a := irc.ParsePrefix("Nick[away]!user@Host.EXAMPLE.com")
+	b := irc.ParsePrefix("nick{AWAY}!user@host.example.com")
+
+	assert.Equal(t, a.Normalized(irc.CaseMappingRFC1459), b.Normalized(irc.CaseMappingRFC1459))
+	assert.Equal(t, "nick{away}!user@host.example.com", a.Normalized(irc.CaseMappingRFC1459).String())
+
+	// With ascii, the brackets aren't equivalent
+	assert.NotEqual(t, a.Normalized(irc.CaseMappingASCII), b.Normalized(irc.CaseMappingASCII))
+
+	// The original should be untouched
+	assert.Equal(t, "Nick[away]!user@Host.EXAMPLE.com", a.String())
+
+	var p *irc.Prefix
+	assert.Nil(t, p.Normalized(irc.CaseMappingRFC1459))
+}
+
 // Everything beyond here comes from the testcases repo
 
 type MsgSplitTests struct {
@@ -348,3 +1173,115 @@ func TestOriginallyParsedThenEditedMessage(t *testing.T) {
 
 	assert.Contains(t, m.String(), "is-cat-lover=1")
 }
+
+func TestUntaggedMessageEditTags(t *testing.T) {
+	t.Parallel()
+
+	// Parsed messages always have a tag map, even without any tags, so tags
+	// can be added to them directly.
+	m := irc.MustParseMessage(":lemuria!lemuria@lemuria.ph PRIVMSG #lemuria meow")
+	assert.NotNil(t, m.Tags)
+	assert.Equal(t, ":lemuria!lemuria@lemuria.ph PRIVMSG #lemuria meow", m.String())
+
+	m.Tags["is-cat"] = "1"
+	assert.Equal(t, "@is-cat=1 :lemuria!lemuria@lemuria.ph PRIVMSG #lemuria meow", m.String())
+
+	c := irc.MustParseMessage("PING").Copy()
+	c.Tags["a"] = "b"
+	assert.Equal(t, "@a=b PING", c.String())
+}
+
+// assertRoundTrip ensures that serializing and re-parsing a message results in
+// the same message.
+func assertRoundTrip(t *testing.T, desc string, m *irc.Message) {
+	t.Helper()
+
+	line := m.String()
+	parsed, err := irc.ParseMessage(line)
+	if !assert.NoError(t, err, "%s: Failed to re-parse: %q", desc, line) {
+		return
+	}
+
+	assert.Equal(t, strings.ToUpper(m.Command), parsed.Command, "%s: Command changed in round-trip of %q", desc, line)
+	assert.Equal(t, len(m.Params), len(parsed.Params), "%s: Param count changed in round-trip of %q", desc, line)
+	if len(m.Params) > 0 {
+		assert.Equal(t, m.Params, parsed.Params, "%s: Params changed in round-trip of %q", desc, line)
+	}
+	assert.Equal(t, len(m.Tags), len(parsed.Tags), "%s: Tag count changed in round-trip of %q", desc, line)
+	for k, v := range m.Tags {
+		assert.Equal(t, v, parsed.Tags[k], "%s: Tag %q changed in round-trip of %q", desc, k, line)
+	}
+
+	if m.Prefix != nil {
+		assert.Equal(t, *m.Prefix, *parsed.Prefix, "%s: Prefix changed in round-trip of %q", desc, line)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var lines = []string{ //nolint:gofumpt
+		"PING",
+		"PING :",
+		"PING ::",
+		"PRIVMSG #chan ::-)",
+		"PRIVMSG #chan :hello world",
+		"PRIVMSG #chan hello",
+		"PRIVMSG #chan :",
+		":@host PRIVMSG #chan :hello",
+		":nick!user@host PRIVMSG #chan :hello",
+		"@a=b;c;d=\\s :irc.example.com 001 nick :Welcome",
+	}
+
+	for _, line := range lines {
+		assertRoundTrip(t, line, irc.MustParseMessage(line))
+	}
+}
+
+func TestMsgSplitRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	data, err := ioutil.ReadFile("./_testcases/tests/msg-split.yaml")
+	require.NoError(t, err)
+
+	var splitTests MsgSplitTests
+	err = yaml.Unmarshal(data, &splitTests)
+	require.NoError(t, err)
+
+	for _, test := range splitTests.Tests {
+		msg, err := irc.ParseMessage(test.Input)
+		if assert.NoError(t, err, "%s: Failed to parse: %s (%s)", test.Desc, test.Input, err) {
+			assertRoundTrip(t, test.Desc, msg)
+		}
+	}
+}
+
+func TestMsgJoinRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	data, err := ioutil.ReadFile("./_testcases/tests/msg-join.yaml")
+	require.NoError(t, err)
+
+	var joinTests MsgJoinTests
+	err = yaml.Unmarshal(data, &joinTests)
+	require.NoError(t, err)
+
+	for _, test := range joinTests.Tests {
+		msg := &irc.Message{
+			Prefix:  irc.ParsePrefix(test.Atoms.Source),
+			Command: test.Atoms.Verb,
+			Params:  test.Atoms.Params,
+			Tags:    make(map[string]string),
+		}
+
+		for k, v := range test.Atoms.Tags {
+			if v != nil {
+				msg.Tags[k], _ = v.(string)
+			} else {
+				msg.Tags[k] = ""
+			}
+		}
+
+		assertRoundTrip(t, test.Desc, msg)
+	}
+}
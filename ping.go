@@ -0,0 +1,144 @@
+package irc
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Pong builds the PONG reply for the given PING message.
+//
+// Clients are sent a PING with a single token, as in "PING :token", which is
+// echoed back unchanged as "PONG :token". Between servers, a PING may have two
+// params, "PING origin destination", where origin is the server which sent it
+// and destination is the server it is meant for. The destination answers with
+// "PONG destination origin", so the params are swapped in the reply.
+func Pong(ping *Message) *Message {
+	params := append([]string(nil), ping.Params...)
+	if len(params) == 2 {
+		params[0], params[1] = params[1], params[0]
+	}
+
+	return &Message{
+		Command: "PONG",
+		Params:  params,
+	}
+}
+
+// IsPong returns true if this is a PONG message.
+func (m *Message) IsPong() bool {
+	return m.Command == "PONG"
+}
+
+// PongToken returns the token echoed back in a PONG message. Servers reply to
+// a client's "PING :token" with "PONG server :token", and between servers the
+// reply to "PING origin destination" is "PONG destination origin", so in both
+// forms the token is the last param. It returns an empty string if this isn't
+// a PONG message.
+func (m *Message) PongToken() string {
+	if !m.IsPong() {
+		return ""
+	}
+
+	return m.Trailing()
+}
+
+// PingTracker generates PING messages with unique tokens and matches them up
+// with the PONG sent in response. Only one PING is tracked at a time, so
+// generating a new one will replace any outstanding token. It is safe for
+// concurrent use and the zero value is ready to use.
+type PingTracker struct {
+	sync.Mutex
+
+	counter uint64
+	token   string
+}
+
+// NextPing generates a PING message with a new unique token and records it as
+// the outstanding token.
+func (t *PingTracker) NextPing() *Message {
+	t.Lock()
+	defer t.Unlock()
+
+	t.counter++
+	t.token = strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + strconv.FormatUint(t.counter, 10)
+
+	return &Message{
+		Command: "PING",
+		Params:  []string{t.token},
+	}
+}
+
+// Outstanding returns the token of the PING which is waiting for a PONG, if
+// there is one.
+func (t *PingTracker) Outstanding() (string, bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	return t.token, t.token != ""
+}
+
+// Matches checks if the given message is a PONG for the outstanding PING. If it
+// is, the outstanding token is cleared.
+func (t *PingTracker) Matches(pong *Message) bool {
+	if !pong.IsPong() {
+		return false
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	if t.token == "" || pong.PongToken() != t.token {
+		return false
+	}
+
+	t.token = ""
+
+	return true
+}
+
+// ActivityTracker records when the last message was received from a
+// connection, so a client can send a keepalive PING once it has been idle for
+// a while (see PingTracker), and give up on the connection if it stays idle.
+// It uses the local wall-clock time when each message is received, not the
+// message's time tag, which may be missing or refer to when a replayed
+// message was originally sent. It is safe for concurrent use and the zero
+// value is ready to use.
+type ActivityTracker struct {
+	sync.Mutex
+
+	// Now returns the current time. If it is nil, time.Now is used. This is
+	// mostly useful for tests.
+	Now func() time.Time
+
+	last time.Time
+}
+
+func (t *ActivityTracker) now() time.Time {
+	if t.Now != nil {
+		return t.Now()
+	}
+
+	return time.Now()
+}
+
+// Touch records that the given message was just received.
+func (t *ActivityTracker) Touch(m *Message) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.last = t.now()
+}
+
+// IdleFor returns how long it has been since the last call to Touch. It
+// returns 0 if Touch hasn't been called yet.
+func (t *ActivityTracker) IdleFor() time.Duration {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.last.IsZero() {
+		return 0
+	}
+
+	return t.now().Sub(t.last)
+}
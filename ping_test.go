@@ -0,0 +1,127 @@
+package irc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestPong(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input  string
+		Expect string
+	}{
+		// Client form
+		{"PING :hello world", "PONG :hello world"},
+		{":irc.example.com PING 1234", "PONG 1234"},
+		// Server form, where the params are swapped
+		{":server1 PING server1 :server2", "PONG server2 server1"},
+		{"PING", "PONG"},
+	}
+
+	for _, testCase := range testCases {
+		ping := irc.MustParseMessage(testCase.Input)
+		assert.Equal(t, testCase.Expect, irc.Pong(ping).String(), "Pong didn't match for %q", testCase.Input)
+	}
+
+	// The PING itself shouldn't be modified
+	ping := irc.MustParseMessage("PING server1 server2")
+	_ = irc.Pong(ping)
+	assert.Equal(t, []string{"server1", "server2"}, ping.Params)
+}
+
+func TestMessagePongToken(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input  string
+		IsPong bool
+		Token  string
+	}{
+		{":irc.example.com PONG irc.example.com :hello world", true, "hello world"},
+		{"PONG 1234", true, "1234"},
+		// Server form, replying to "PING server1 server2"
+		{":server2 PONG server2 server1", true, "server1"},
+		{"PONG", true, ""},
+		{"PING :1234", false, ""},
+	}
+
+	for _, testCase := range testCases {
+		m := irc.MustParseMessage(testCase.Input)
+		assert.Equal(t, testCase.IsPong, m.IsPong(), "IsPong didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Token, m.PongToken(), "PongToken didn't match for %q", testCase.Input)
+	}
+
+	// A PONG built with Pong should echo the PING's token
+	for _, line := range []string{"PING :hello world", "PING server1 server2"} {
+		ping := irc.MustParseMessage(line)
+		assert.Equal(t, ping.Param(0), irc.Pong(ping).PongToken(), "PongToken didn't match for %q", line)
+	}
+}
+
+func TestPingTracker(t *testing.T) {
+	t.Parallel()
+
+	tracker := &irc.PingTracker{}
+
+	_, ok := tracker.Outstanding()
+	assert.False(t, ok)
+
+	ping := tracker.NextPing()
+	assert.Equal(t, "PING", ping.Command)
+
+	token, ok := tracker.Outstanding()
+	assert.True(t, ok)
+	assert.Equal(t, token, ping.Trailing())
+
+	// Tokens should be unique
+	ping = tracker.NextPing()
+	assert.NotEqual(t, token, ping.Trailing())
+	token = ping.Trailing()
+
+	// Non-matching messages shouldn't clear the token
+	assert.False(t, tracker.Matches(irc.MustParseMessage(":irc.example.com PONG irc.example.com :wrong")))
+	assert.False(t, tracker.Matches(irc.MustParseMessage("PING :"+token)))
+
+	_, ok = tracker.Outstanding()
+	assert.True(t, ok)
+
+	// Feed back a matching PONG as the server would send it
+	pong := irc.MustParseMessage(":irc.example.com PONG irc.example.com :" + token)
+	assert.True(t, tracker.Matches(pong))
+
+	_, ok = tracker.Outstanding()
+	assert.False(t, ok)
+
+	// Once matched, the same PONG shouldn't match again
+	assert.False(t, tracker.Matches(pong))
+}
+
+func TestActivityTracker(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	tracker := &irc.ActivityTracker{
+		Now: func() time.Time { return now },
+	}
+
+	assert.Equal(t, time.Duration(0), tracker.IdleFor())
+
+	tracker.Touch(irc.MustParseMessage("PING :hello"))
+	assert.Equal(t, time.Duration(0), tracker.IdleFor())
+
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, 30*time.Second, tracker.IdleFor())
+
+	// The time tag should be ignored in favor of our own clock
+	tracker.Touch(irc.MustParseMessage("@time=2019-01-01T00:00:00.000Z :nick!user@host PRIVMSG #chan :hello"))
+	assert.Equal(t, time.Duration(0), tracker.IdleFor())
+
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, 2*time.Minute, tracker.IdleFor())
+}
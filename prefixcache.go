@@ -0,0 +1,52 @@
+package irc
+
+import "sync"
+
+// DefaultPrefixCacheSize is the number of prefixes a PrefixCache will hold if
+// no size is given.
+const DefaultPrefixCacheSize = 1024
+
+// PrefixCache remembers the serialized form of recently seen prefixes, to avoid
+// building the same string repeatedly when relaying many messages from the
+// same users. It is safe for concurrent use.
+type PrefixCache struct {
+	sync.Mutex
+
+	size  int
+	cache map[Prefix]string
+}
+
+// NewPrefixCache creates a PrefixCache holding at most size prefixes. If size
+// is zero or negative, DefaultPrefixCacheSize is used. When the cache is full,
+// it is cleared before adding more.
+func NewPrefixCache(size int) *PrefixCache {
+	if size <= 0 {
+		size = DefaultPrefixCacheSize
+	}
+
+	return &PrefixCache{
+		size:  size,
+		cache: make(map[Prefix]string),
+	}
+}
+
+// String returns the same value as p.String(), using the cached value if there
+// is one. As prefixes are looked up by value, modifying a Prefix after it has
+// been cached is safe.
+func (c *PrefixCache) String(p *Prefix) string {
+	c.Lock()
+	defer c.Unlock()
+
+	if ret, ok := c.cache[*p]; ok {
+		return ret
+	}
+
+	if len(c.cache) >= c.size {
+		c.cache = make(map[Prefix]string)
+	}
+
+	ret := p.String()
+	c.cache[*p] = ret
+
+	return ret
+}
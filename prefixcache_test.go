@@ -0,0 +1,61 @@
+package irc_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestPrefixCache(t *testing.T) {
+	t.Parallel()
+
+	c := irc.NewPrefixCache(2)
+
+	p := irc.ParsePrefix("nick!user@host")
+	assert.Equal(t, "nick!user@host", c.String(p))
+	assert.Equal(t, "nick!user@host", c.String(p))
+
+	// Changing the prefix shouldn't return the old value
+	p.Name = "other"
+	assert.Equal(t, "other!user@host", c.String(p))
+
+	// Go over the size limit
+	for _, input := range []string{"a!b@c", "irc.example.com", "nick@host"} {
+		assert.Equal(t, input, c.String(irc.ParsePrefix(input)))
+	}
+
+	assert.Equal(t, "nick!user@host", c.String(irc.ParsePrefix("nick!user@host")))
+}
+
+// forwardingPrefixes simulates the senders in a busy channel being relayed.
+func forwardingPrefixes() []*irc.Prefix {
+	var ret []*irc.Prefix
+	for i := 0; i < 1000; i++ {
+		n := strconv.Itoa(i % 20)
+		ret = append(ret, irc.ParsePrefix("nick"+n+"!~user"+n+"@user/"+n+"/cloak.example.com"))
+	}
+
+	return ret
+}
+
+func BenchmarkPrefixString(b *testing.B) {
+	prefixes := forwardingPrefixes()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = prefixes[i%len(prefixes)].String()
+	}
+}
+
+func BenchmarkPrefixCacheString(b *testing.B) {
+	prefixes := forwardingPrefixes()
+	c := irc.NewPrefixCache(0)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = c.String(prefixes[i%len(prefixes)])
+	}
+}
@@ -0,0 +1,110 @@
+package irc
+
+import (
+	"strconv"
+	"time"
+)
+
+// rplTopicWhoTime isn't in any RFC, so it isn't in the numerics list, but it is
+// sent by almost every server along with RPL_TOPIC.
+const rplTopicWhoTime = "333" // ircu
+
+// ParseTopicReply parses an RPL_TOPIC message.
+//
+//	332    RPL_TOPIC
+//	       "<client> <channel> :<topic>"
+func ParseTopicReply(m *Message) (channel, topic string, ok bool) {
+	if m.Command != RPL_TOPIC || len(m.Params) < 3 {
+		return "", "", false
+	}
+
+	return m.Params[1], m.Params[2], true
+}
+
+// ParseTopicWhoTime parses an RPL_TOPICWHOTIME message. Depending on the
+// server, setBy may be either a nick or a full nick!user@host mask.
+//
+//	333    RPL_TOPICWHOTIME
+//	       "<client> <channel> <nick> <setat>"
+func ParseTopicWhoTime(m *Message) (channel, setBy string, setAt time.Time, ok bool) {
+	if m.Command != rplTopicWhoTime || len(m.Params) < 4 {
+		return "", "", time.Time{}, false
+	}
+
+	timestamp, err := strconv.ParseInt(m.Params[3], 10, 64)
+	if err != nil {
+		return "", "", time.Time{}, false
+	}
+
+	return m.Params[1], m.Params[2], time.Unix(timestamp, 0), true
+}
+
+// ParseChannelModeIs parses an RPL_CHANNELMODEIS message, sent in response to
+// querying a channel's modes, into the changes needed to reach the current
+// modes. These can be passed to ChannelModes.Apply. The mode string is parsed
+// with ParseModeChanges, so supports is used to match up modes with their
+// params. It returns false if the mode string doesn't match its params.
+//
+//	324    RPL_CHANNELMODEIS
+//	       "<client> <channel> <modestring> <mode arguments>..."
+func ParseChannelModeIs(m *Message, supports ISupport) (channel string, changes []ModeChange, ok bool) {
+	if m.Command != RPL_CHANNELMODEIS || len(m.Params) < 3 {
+		return "", nil, false
+	}
+
+	changes, err := ParseModeChanges(supports, m.Params[2], m.Params[3:])
+	if err != nil {
+		return "", nil, false
+	}
+
+	return m.Params[1], changes, true
+}
+
+// ErrorTarget returns the subject of an error numeric (400-599), which is the
+// param after our nick. Most errors follow the "<client> <subject> :<reason>"
+// format, so this is useful for things like:
+//
+//   - ERR_NOSUCHNICK (401), ERR_NOSUCHCHANNEL (403), ERR_CANNOTSENDTOCHAN (404):
+//     the unknown nick or channel
+//   - ERR_ERRONEUSNICKNAME (432), ERR_NICKNAMEINUSE (433),
+//     ERR_NICKCOLLISION (436): the rejected nick
+//   - ERR_CHANNELISFULL (471), ERR_INVITEONLYCHAN (473),
+//     ERR_BANNEDFROMCHAN (474), ERR_BADCHANNELKEY (475): the channel which
+//     couldn't be joined
+//
+// It returns false if this isn't an error numeric or there is no subject, such
+// as for ERR_NOTREGISTERED (451).
+func (m *Message) ErrorTarget() (string, bool) {
+	numeric, ok := m.Numeric()
+	if !ok || numeric < 400 || numeric > 599 {
+		return "", false
+	}
+
+	if len(m.Params) < 3 {
+		return "", false
+	}
+
+	return m.Params[1], true
+}
+
+// NumericText returns the human-readable text of a numeric reply, which is the
+// last param. It returns an empty string if this isn't a numeric or there are
+// no params after our nick.
+func (m *Message) NumericText() string {
+	if !m.IsNumeric() || len(m.Params) < 2 {
+		return ""
+	}
+
+	return m.Trailing()
+}
+
+// NumericArgs returns the params of a numeric reply between our nick and the
+// text returned by NumericText. It returns nil if this isn't a numeric or
+// there are no params in between.
+func (m *Message) NumericArgs() []string {
+	if !m.IsNumeric() || len(m.Params) < 3 {
+		return nil
+	}
+
+	return m.Params[1 : len(m.Params)-1]
+}
@@ -0,0 +1,169 @@
+package irc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestParseTopicReply(t *testing.T) {
+	t.Parallel()
+
+	channel, topic, ok := irc.ParseTopicReply(irc.MustParseMessage(":irc.example.com 332 me #chan :Welcome to #chan: be nice"))
+	assert.True(t, ok)
+	assert.Equal(t, "#chan", channel)
+	assert.Equal(t, "Welcome to #chan: be nice", topic)
+
+	_, _, ok = irc.ParseTopicReply(irc.MustParseMessage(":irc.example.com 332 me #chan"))
+	assert.False(t, ok)
+
+	_, _, ok = irc.ParseTopicReply(irc.MustParseMessage(":irc.example.com 333 me #chan nick 1600000000"))
+	assert.False(t, ok)
+}
+
+func TestParseTopicWhoTime(t *testing.T) {
+	t.Parallel()
+
+	channel, setBy, setAt, ok := irc.ParseTopicWhoTime(irc.MustParseMessage(":irc.example.com 333 me #chan nick!user@host 1600000000"))
+	assert.True(t, ok)
+	assert.Equal(t, "#chan", channel)
+	assert.Equal(t, "nick!user@host", setBy)
+	assert.True(t, time.Date(2020, time.September, 13, 12, 26, 40, 0, time.UTC).Equal(setAt))
+
+	_, _, _, ok = irc.ParseTopicWhoTime(irc.MustParseMessage(":irc.example.com 333 me #chan nick :not-a-time"))
+	assert.False(t, ok)
+
+	_, _, _, ok = irc.ParseTopicWhoTime(irc.MustParseMessage(":irc.example.com 333 me #chan nick"))
+	assert.False(t, ok)
+
+	_, _, _, ok = irc.ParseTopicWhoTime(irc.MustParseMessage(":irc.example.com 332 me #chan :topic"))
+	assert.False(t, ok)
+}
+
+func TestParseChannelModeIs(t *testing.T) {
+	t.Parallel()
+
+	supports := irc.ISupport{
+		"CHANMODES": "beI,k,l,imnpst",
+		"PREFIX":    "(ov)@+",
+	}
+
+	channel, changes, ok := irc.ParseChannelModeIs(irc.MustParseMessage(":irc.example.com 324 me #chan +ntkl key 50"), supports)
+	assert.True(t, ok)
+	assert.Equal(t, "#chan", channel)
+	assert.Equal(t, []irc.ModeChange{
+		{Add: true, Mode: 'n', Type: irc.ModeTypeD},
+		{Add: true, Mode: 't', Type: irc.ModeTypeD},
+		{Add: true, Mode: 'k', Param: "key", Type: irc.ModeTypeB},
+		{Add: true, Mode: 'l', Param: "50", Type: irc.ModeTypeC},
+	}, changes)
+
+	modes := irc.NewChannelModes()
+	modes.Apply(changes)
+	assert.Equal(t, "key", modes.Params['k'])
+	assert.Equal(t, "50", modes.Params['l'])
+
+	// A channel without any modes set
+	channel, changes, ok = irc.ParseChannelModeIs(irc.MustParseMessage(":irc.example.com 324 me #chan +"), supports)
+	assert.True(t, ok)
+	assert.Equal(t, "#chan", channel)
+	assert.Empty(t, changes)
+
+	// Missing the param for +k
+	_, _, ok = irc.ParseChannelModeIs(irc.MustParseMessage(":irc.example.com 324 me #chan +k"), supports)
+	assert.False(t, ok)
+
+	_, _, ok = irc.ParseChannelModeIs(irc.MustParseMessage(":irc.example.com 324 me #chan"), supports)
+	assert.False(t, ok)
+
+	_, _, ok = irc.ParseChannelModeIs(irc.MustParseMessage(":irc.example.com 332 me #chan :+nt"), supports)
+	assert.False(t, ok)
+}
+
+func TestMessageErrorTarget(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input  string
+		Target string
+		OK     bool
+	}{
+		{
+			Input:  ":irc.example.com 433 * lemuria :Nickname is already in use",
+			Target: "lemuria",
+			OK:     true,
+		},
+		{
+			Input:  ":irc.example.com 474 lemuria #lemuria :Cannot join channel (+b)",
+			Target: "#lemuria",
+			OK:     true,
+		},
+		{
+			// No subject
+			Input: ":irc.example.com 451 * :You have not registered",
+		},
+		{
+			// Not an error
+			Input: ":irc.example.com 332 lemuria #lemuria :topic",
+		},
+		{
+			Input: "PRIVMSG #lemuria :hello world",
+		},
+	}
+
+	for _, testCase := range testCases {
+		target, ok := irc.MustParseMessage(testCase.Input).ErrorTarget()
+		assert.Equal(t, testCase.OK, ok, "ok didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Target, target, "target didn't match for %q", testCase.Input)
+	}
+}
+
+func TestMessageNumericTextArgs(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input string
+		Text  string
+		Args  []string
+	}{
+		{
+			Input: ":irc.example.com 375 lemuria :- irc.example.com Message of the day -",
+			Text:  "- irc.example.com Message of the day -",
+		},
+		{
+			Input: ":irc.example.com 372 lemuria :- Welcome!",
+			Text:  "- Welcome!",
+		},
+		{
+			Input: ":irc.example.com 376 lemuria :End of /MOTD command.",
+			Text:  "End of /MOTD command.",
+		},
+		{
+			Input: ":irc.example.com 332 lemuria #lemuria :the topic",
+			Text:  "the topic",
+			Args:  []string{"#lemuria"},
+		},
+		{
+			Input: ":irc.example.com 333 lemuria #lemuria nick 1600000000",
+			Text:  "1600000000",
+			Args:  []string{"#lemuria", "nick"},
+		},
+		{
+			// Only our nick
+			Input: ":irc.example.com 999 lemuria",
+		},
+		{
+			// Not a numeric
+			Input: ":nick!user@host PRIVMSG #lemuria :hello",
+		},
+	}
+
+	for _, testCase := range testCases {
+		m := irc.MustParseMessage(testCase.Input)
+		assert.Equal(t, testCase.Text, m.NumericText(), "text didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.Args, m.NumericArgs(), "args didn't match for %q", testCase.Input)
+	}
+}
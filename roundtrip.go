@@ -0,0 +1,61 @@
+package irc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// AssertRoundTrip parses line, serializes the result and parses it again,
+// returning an error describing any difference between the two messages. It is
+// meant for checking test fixtures. Messages are compared by their contents,
+// so differences which don't change the meaning of the line, such as the order
+// of tags or whether a trailing param had a colon, are ignored.
+func AssertRoundTrip(line string) error {
+	first, err := ParseMessage(line)
+	if err != nil {
+		return fmt.Errorf("irc: failed to parse %q: %w", line, err)
+	}
+
+	serialized := first.String()
+
+	second, err := ParseMessage(serialized)
+	if err != nil {
+		return fmt.Errorf("irc: failed to parse %q serialized as %q: %w", line, serialized, err)
+	}
+
+	if diff := messageDiff(first, second); diff != "" {
+		return fmt.Errorf("irc: %q serialized as %q: %s", line, serialized, diff)
+	}
+
+	return nil
+}
+
+// messageDiff describes the first difference found between two messages, or
+// returns an empty string if they are equivalent. Empty and nil tags or params
+// are treated as equal.
+func messageDiff(a, b *Message) string {
+	if a.Command != b.Command {
+		return fmt.Sprintf("command changed from %q to %q", a.Command, b.Command)
+	}
+
+	var aPrefix, bPrefix Prefix
+	if a.Prefix != nil {
+		aPrefix = *a.Prefix
+	}
+	if b.Prefix != nil {
+		bPrefix = *b.Prefix
+	}
+	if aPrefix != bPrefix {
+		return fmt.Sprintf("prefix changed from %q to %q", aPrefix.String(), bPrefix.String())
+	}
+
+	if len(a.Params) != len(b.Params) || (len(a.Params) > 0 && !reflect.DeepEqual(a.Params, b.Params)) {
+		return fmt.Sprintf("params changed from %q to %q", a.Params, b.Params)
+	}
+
+	if len(a.Tags) != len(b.Tags) || (len(a.Tags) > 0 && !reflect.DeepEqual(a.Tags, b.Tags)) {
+		return fmt.Sprintf("tags changed from %v to %v", map[string]string(a.Tags), map[string]string(b.Tags))
+	}
+
+	return ""
+}
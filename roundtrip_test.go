@@ -0,0 +1,29 @@
+package irc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestAssertRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, line := range []string{
+		"PING",
+		"PING :hello",
+		":nick!user@host PRIVMSG #chan :hello world",
+		"@b=2;a=1;c :nick!user@host PRIVMSG #chan :hello world",
+		"@a=escaped\\svalue\\:here PRIVMSG #chan ::)",
+		":irc.example.com 005 nick CHANTYPES=# :are supported by this server",
+		"PRIVMSG #chan :",
+	} {
+		assert.NoError(t, irc.AssertRoundTrip(line), "%q should round-trip", line)
+	}
+
+	err := irc.AssertRoundTrip(":nick")
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, irc.ErrMissingDataAfterPrefix)
+}
@@ -0,0 +1,72 @@
+package irc
+
+import (
+	"strings"
+	"sync"
+)
+
+// Router dispatches messages to handlers based on their command. It isn't tied
+// to any connection, so it can be used with a Client, a Conn or anything else
+// producing messages. It is safe for concurrent use.
+//
+// To use a Router with a Client, set ClientConfig.Handler to
+// HandlerFunc(router.Dispatch).
+type Router struct {
+	sync.RWMutex
+
+	handlers map[string][]Handler
+	fallback []Handler
+}
+
+// NewRouter creates a new Router with no handlers.
+func NewRouter() *Router {
+	return &Router{
+		handlers: make(map[string][]Handler),
+	}
+}
+
+// Handle registers h to be called for all messages with the given command.
+// Commands are matched case-insensitively. Multiple handlers may be registered
+// for the same command.
+func (r *Router) Handle(command string, h Handler) {
+	r.Lock()
+	defer r.Unlock()
+
+	command = strings.ToUpper(command)
+	r.handlers[command] = append(r.handlers[command], h)
+}
+
+// HandleFunc registers fn to be called for all messages with the given
+// command. It is a shortcut for Handle(command, HandlerFunc(fn)).
+func (r *Router) HandleFunc(command string, fn func(*Client, *Message)) {
+	r.Handle(command, HandlerFunc(fn))
+}
+
+// HandleDefault registers h to be called for all messages which don't have
+// any handlers registered for their command.
+func (r *Router) HandleDefault(h Handler) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.fallback = append(r.fallback, h)
+}
+
+// Dispatch calls all handlers registered for the message's command, or the
+// default handlers if there are none. Handlers are called synchronously in the
+// order they were registered. Handlers may register other handlers, but they
+// will only be used for later messages.
+//
+// The Client is passed through to the handlers as-is, so it may be nil if the
+// messages aren't coming from a Client.
+func (r *Router) Dispatch(c *Client, m *Message) {
+	r.RLock()
+	handlers, ok := r.handlers[strings.ToUpper(m.Command)]
+	if !ok {
+		handlers = r.fallback
+	}
+	r.RUnlock()
+
+	for _, h := range handlers {
+		h.Handle(c, m)
+	}
+}
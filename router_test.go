@@ -0,0 +1,93 @@
+package irc_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestRouter(t *testing.T) {
+	t.Parallel()
+
+	r := irc.NewRouter()
+
+	var calls []string
+	r.HandleFunc("PRIVMSG", func(c *irc.Client, m *irc.Message) {
+		calls = append(calls, "privmsg1:"+m.Trailing())
+	})
+	r.Handle("privmsg", irc.HandlerFunc(func(c *irc.Client, m *irc.Message) {
+		calls = append(calls, "privmsg2:"+m.Trailing())
+	}))
+	r.HandleFunc("001", func(c *irc.Client, m *irc.Message) {
+		calls = append(calls, "welcome")
+	})
+
+	// Without a default handler, unknown messages should be dropped
+	r.Dispatch(nil, irc.MustParseMessage("NOTICE #chan :dropped"))
+	assert.Nil(t, calls)
+
+	r.HandleDefault(irc.HandlerFunc(func(c *irc.Client, m *irc.Message) {
+		calls = append(calls, "default:"+m.Command)
+	}))
+
+	r.Dispatch(nil, irc.MustParseMessage(":nick!user@host PRIVMSG #chan :hello"))
+	r.Dispatch(nil, &irc.Message{Command: "privmsg", Params: []string{"#chan", "lower"}})
+	r.Dispatch(nil, irc.MustParseMessage(":irc.example.com 001 nick :Welcome"))
+	r.Dispatch(nil, irc.MustParseMessage("NOTICE #chan :hello"))
+
+	assert.Equal(t, []string{
+		"privmsg1:hello",
+		"privmsg2:hello",
+		"privmsg1:lower",
+		"privmsg2:lower",
+		"welcome",
+		"default:NOTICE",
+	}, calls)
+}
+
+func TestRouterRegisterFromHandler(t *testing.T) {
+	t.Parallel()
+
+	r := irc.NewRouter()
+
+	count := 0
+	r.HandleFunc("PING", func(c *irc.Client, m *irc.Message) {
+		// This shouldn't deadlock
+		r.HandleFunc("PING", func(c *irc.Client, m *irc.Message) {
+			count++
+		})
+	})
+
+	r.Dispatch(nil, irc.MustParseMessage("PING :1"))
+	assert.Equal(t, 0, count)
+
+	r.Dispatch(nil, irc.MustParseMessage("PING :2"))
+	assert.Equal(t, 1, count)
+}
+
+func TestRouterWithClient(t *testing.T) {
+	t.Parallel()
+
+	r := irc.NewRouter()
+	r.HandleFunc("PRIVMSG", func(c *irc.Client, m *irc.Message) {
+		_ = c.Writef("PRIVMSG %s :echo %s", m.Prefix.Name, m.Trailing())
+	})
+
+	config := irc.ClientConfig{
+		Nick: "test_nick",
+		User: "test_user",
+		Name: "test_name",
+
+		Handler: irc.HandlerFunc(r.Dispatch),
+	}
+
+	runClientTest(t, config, io.EOF, nil, []TestAction{
+		ExpectLine("NICK :test_nick\r\n"),
+		ExpectLine("USER test_user 0 * :test_name\r\n"),
+		SendLine(":nick!user@host PRIVMSG test_nick :hello\r\n"),
+		ExpectLine("PRIVMSG nick :echo hello\r\n"),
+	})
+}
@@ -0,0 +1,47 @@
+package irc
+
+import "encoding/base64"
+
+// AuthenticateChunkSize is the maximum length of the base64 data in a single
+// AUTHENTICATE message. Longer payloads are split into multiple messages.
+const AuthenticateChunkSize = 400
+
+// IsAuthenticate returns true if this is an AUTHENTICATE message, used during
+// SASL authentication.
+func (m *Message) IsAuthenticate() bool {
+	return m.Command == "AUTHENTICATE" && len(m.Params) > 0
+}
+
+// AuthenticatePayload decodes the base64 data from an AUTHENTICATE message. An
+// empty payload, sent as "AUTHENTICATE +", is returned as an empty, non-nil
+// slice. It returns false if this isn't an AUTHENTICATE message or the data
+// isn't valid base64.
+//
+// As AuthenticateChunkSize is a multiple of 4, each chunk of a longer payload
+// can be decoded separately and the results appended together. See
+// AuthenticateContinues.
+func (m *Message) AuthenticatePayload() ([]byte, bool) {
+	if !m.IsAuthenticate() {
+		return nil, false
+	}
+
+	data := m.Params[0]
+	if data == "+" {
+		return []byte{}, true
+	}
+
+	ret, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, false
+	}
+
+	return ret, true
+}
+
+// AuthenticateContinues returns true if this AUTHENTICATE message is a full
+// chunk, meaning more of the payload will follow in another AUTHENTICATE
+// message. If a payload is an exact multiple of AuthenticateChunkSize, it ends
+// with an empty "AUTHENTICATE +" message.
+func (m *Message) AuthenticateContinues() bool {
+	return m.IsAuthenticate() && len(m.Params[0]) == AuthenticateChunkSize
+}
@@ -0,0 +1,67 @@
+package irc_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestMessageAuthenticatePayload(t *testing.T) {
+	t.Parallel()
+
+	// The server asking for our payload
+	m := irc.MustParseMessage("AUTHENTICATE +")
+	assert.True(t, m.IsAuthenticate())
+	payload, ok := m.AuthenticatePayload()
+	assert.True(t, ok)
+	assert.NotNil(t, payload)
+	assert.Empty(t, payload)
+	assert.False(t, m.AuthenticateContinues())
+
+	// A challenge
+	m = irc.MustParseMessage("AUTHENTICATE " + base64.StdEncoding.EncodeToString([]byte("r=abc,s=salt,i=4096")))
+	payload, ok = m.AuthenticatePayload()
+	assert.True(t, ok)
+	assert.Equal(t, []byte("r=abc,s=salt,i=4096"), payload)
+	assert.False(t, m.AuthenticateContinues())
+
+	// Invalid base64
+	_, ok = irc.MustParseMessage("AUTHENTICATE !!!").AuthenticatePayload()
+	assert.False(t, ok)
+
+	// Not AUTHENTICATE at all
+	m = irc.MustParseMessage("PRIVMSG #chan :+")
+	assert.False(t, m.IsAuthenticate())
+	_, ok = m.AuthenticatePayload()
+	assert.False(t, ok)
+	assert.False(t, m.AuthenticateContinues())
+}
+
+func TestMessageAuthenticateChunked(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(strings.Repeat("x", 350))
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	lines := []string{
+		"AUTHENTICATE " + encoded[:irc.AuthenticateChunkSize],
+		"AUTHENTICATE " + encoded[irc.AuthenticateChunkSize:],
+	}
+
+	var payload []byte
+	for i, line := range lines {
+		m := irc.MustParseMessage(line)
+
+		chunk, ok := m.AuthenticatePayload()
+		assert.True(t, ok)
+		payload = append(payload, chunk...)
+
+		assert.Equal(t, i < len(lines)-1, m.AuthenticateContinues())
+	}
+
+	assert.Equal(t, data, payload)
+}
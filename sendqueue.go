@@ -0,0 +1,109 @@
+package irc
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// DefaultSendQueueSize is the number of messages a SendQueue will buffer if no
+// size is given.
+const DefaultSendQueueSize = 64
+
+// SendQueue buffers outgoing messages and releases them at a limited rate to
+// avoid being disconnected for flooding. It uses a token bucket, so a burst of
+// messages may be sent at once, after which messages are released once per
+// interval. It is safe for concurrent use.
+type SendQueue struct {
+	limiter   *rate.Limiter
+	queue     chan *Message
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSendQueue creates a SendQueue which releases one message per interval,
+// allowing up to burst messages at once. If interval is zero, there will be no
+// limit. If burst is zero, it will be treated as one. At most size messages
+// will be buffered, or DefaultSendQueueSize if size is zero.
+func NewSendQueue(interval time.Duration, burst, size int) *SendQueue {
+	if burst <= 0 {
+		burst = 1
+	}
+
+	if size <= 0 {
+		size = DefaultSendQueueSize
+	}
+
+	q := &SendQueue{
+		queue: make(chan *Message, size),
+		done:  make(chan struct{}),
+	}
+
+	if interval > 0 {
+		q.limiter = rate.NewLimiter(rate.Every(interval), burst)
+	}
+
+	return q
+}
+
+// Enqueue adds a message to the queue. It never blocks. If the queue is full or
+// has been closed, the message is dropped and false is returned.
+func (q *SendQueue) Enqueue(m *Message) bool {
+	select {
+	case <-q.done:
+		return false
+	default:
+	}
+
+	select {
+	case q.queue <- m:
+		return true
+	default:
+		return false
+	}
+}
+
+// Next blocks until a message is available and the rate limit allows it to be
+// sent, then returns it. It returns nil once the queue has been closed.
+func (q *SendQueue) Next() *Message {
+	var m *Message
+
+	// If the queue was closed, we want to stop even if there are still
+	// messages buffered.
+	select {
+	case <-q.done:
+		return nil
+	default:
+	}
+
+	select {
+	case m = <-q.queue:
+	case <-q.done:
+		return nil
+	}
+
+	if q.limiter == nil {
+		return m
+	}
+
+	r := q.limiter.Reserve()
+	timer := time.NewTimer(r.Delay())
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return m
+	case <-q.done:
+		r.Cancel()
+		return nil
+	}
+}
+
+// Close stops the queue. Any pending calls to Next will return nil and any
+// buffered messages are dropped.
+func (q *SendQueue) Close() {
+	q.closeOnce.Do(func() {
+		close(q.done)
+	})
+}
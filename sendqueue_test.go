@@ -0,0 +1,72 @@
+package irc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestSendQueueRate(t *testing.T) {
+	t.Parallel()
+
+	q := irc.NewSendQueue(50*time.Millisecond, 2, 0)
+	defer q.Close()
+
+	for i := 0; i < 4; i++ {
+		assert.True(t, q.Enqueue(&irc.Message{Command: "PING", Params: []string{"test"}}))
+	}
+
+	// The first two should be sent as a burst
+	before := time.Now()
+	assert.NotNil(t, q.Next())
+	assert.NotNil(t, q.Next())
+	assert.WithinDuration(t, before, time.Now(), 20*time.Millisecond)
+
+	// The rest should be limited
+	assert.NotNil(t, q.Next())
+	assert.NotNil(t, q.Next())
+	assert.True(t, time.Since(before) >= 90*time.Millisecond, "Messages were not rate limited")
+}
+
+func TestSendQueueDrop(t *testing.T) {
+	t.Parallel()
+
+	q := irc.NewSendQueue(0, 0, 2)
+
+	m := &irc.Message{Command: "PING", Params: []string{"test"}}
+
+	// Enqueue should never block, even when full.
+	assert.True(t, q.Enqueue(m))
+	assert.True(t, q.Enqueue(m))
+	assert.False(t, q.Enqueue(m))
+
+	assert.Equal(t, m, q.Next())
+	assert.True(t, q.Enqueue(m))
+
+	// Once closed, nothing else should be sent.
+	q.Close()
+	q.Close()
+	assert.False(t, q.Enqueue(m))
+	assert.Nil(t, q.Next())
+}
+
+func TestSendQueueCloseWhileWaiting(t *testing.T) {
+	t.Parallel()
+
+	q := irc.NewSendQueue(time.Hour, 1, 0)
+
+	m := &irc.Message{Command: "PING", Params: []string{"test"}}
+	assert.True(t, q.Enqueue(m))
+	assert.True(t, q.Enqueue(m))
+	assert.Equal(t, m, q.Next())
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Close()
+	}()
+
+	assert.Nil(t, q.Next())
+}
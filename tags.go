@@ -0,0 +1,185 @@
+package irc
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// IsSet returns true if the given tag is present, whether or not it has a
+// value.
+func (t Tags) IsSet(key string) bool {
+	_, ok := t[key]
+	return ok
+}
+
+// HasValue returns true if the given tag is present and has a non-empty value.
+//
+// Note that the IRCv3 spec requires an empty value (@foo=) to be treated the
+// same as a missing value (@foo), so both are stored as an empty string and
+// HasValue will return false for either of them. Use Message.TagHasValue to
+// tell them apart on a parsed message.
+func (t Tags) HasValue(key string) bool {
+	return t[key] != ""
+}
+
+// TagHasValue returns true if the given tag is present and was sent with a
+// value, even an empty one. Unlike Tags.HasValue, this returns true for @foo=
+// but not for @foo, as long as the message was parsed and the tag's value is
+// still empty.
+func (m *Message) TagHasValue(key string) bool {
+	value, ok := m.Tags[key]
+	if !ok {
+		return false
+	}
+
+	return value != "" || explicitlyEmptyTag(m.originalTags, key)
+}
+
+// explicitlyEmptyTag returns true if the given tag is in the raw tag string
+// with an empty value, as in "foo=", rather than no value at all. As with
+// ParseTags, if a tag is repeated, the last one wins.
+func explicitlyEmptyTag(raw, key string) bool {
+	ret := false
+
+	for _, tag := range strings.Split(raw, ";") {
+		if !strings.HasPrefix(tag, key) {
+			continue
+		}
+
+		if rest := tag[len(key):]; rest == "" || rest[0] == '=' {
+			ret = rest == "="
+		}
+	}
+
+	return ret
+}
+
+// clientTagKey adds the '+' which marks a client-only tag to name, unless it
+// already has one.
+func clientTagKey(name string) string {
+	if strings.HasPrefix(name, "+") {
+		return name
+	}
+
+	return "+" + name
+}
+
+// SetClient sets the client-only tag with the given name, such as
+// "draft/channel-context" or "typing". The leading '+' is added if it is
+// missing.
+func (t Tags) SetClient(name, value string) {
+	t[clientTagKey(name)] = value
+}
+
+// GetClient returns the value of the client-only tag with the given name,
+// adding the leading '+' if it is missing. It returns false if the tag isn't
+// set.
+func (t Tags) GetClient(name string) (string, bool) {
+	value, ok := t[clientTagKey(name)]
+	return value, ok
+}
+
+// Keys returns the tag keys sorted alphabetically, for when the tags need to
+// be listed in a stable order regardless of AlphabetizeTagMaps.
+func (t Tags) Keys() []string {
+	return t.sortedKeys(TagSortAlphabetical)
+}
+
+// Range calls fn for each tag, in map order, which is random. It stops early
+// if fn returns false. Unlike Keys, this doesn't allocate.
+func (t Tags) Range(fn func(k, v string) bool) {
+	for k, v := range t {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
+
+// MsgID returns the value of the msgid tag, which uniquely identifies a
+// message. It returns false if the tag is missing or empty.
+func (m *Message) MsgID() (string, bool) {
+	id := m.Tags["msgid"]
+	return id, id != ""
+}
+
+// Label returns the value of the label tag, which the server copies from a
+// command sent with the labeled-response capability to the responses to it. It
+// returns false if the tag is missing or empty.
+func (m *Message) Label() (string, bool) {
+	label := m.Tags["label"]
+	return label, label != ""
+}
+
+// botTags are the tags servers use to mark messages from users with the bot
+// mode set. The draft name is still sent by some servers.
+var botTags = []string{"bot", "draft/bot"}
+
+// IsFromBot returns true if the message has a bot tag, which servers supporting
+// the IRCv3 bot mode add to messages sent by users marked as bots. The tag has
+// no value, so only its presence is checked.
+func (m *Message) IsFromBot() bool {
+	for _, key := range botTags {
+		if m.Tags.IsSet(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Merge copies all the tags from other into t. Tags which are already set in
+// t are only replaced if overwrite is true. As t is modified in place, it must
+// not be nil unless other is empty.
+func (t Tags) Merge(other Tags, overwrite bool) {
+	for k, v := range other {
+		if _, ok := t[k]; ok && !overwrite {
+			continue
+		}
+
+		t[k] = v
+	}
+}
+
+// EscapedTagLen returns the length in bytes of value once it has been escaped
+// by EncodeTagValue, without building the escaped string. This can be used to
+// check if a tag will fit in the tag length limit before adding it.
+func EscapedTagLen(value string) int {
+	ret := 0
+
+	for _, c := range value {
+		if replacement, ok := tagEncodeMap[c]; ok {
+			ret += len(replacement)
+		} else {
+			// Invalid UTF-8 is replaced when encoding, so this needs to
+			// use the length of the replacement rune rather than the
+			// original byte.
+			ret += utf8.RuneLen(c)
+		}
+	}
+
+	return ret
+}
+
+// SplitTagKey splits a tag key into its parts. client will be true if the key
+// starts with a + (marking it as a client-only tag), vendor will contain the
+// optional vendor prefix (without the trailing /) and name will contain the
+// rest of the key.
+func SplitTagKey(key string) (vendor, name string, client bool) {
+	if strings.HasPrefix(key, "+") {
+		client = true
+		key = key[1:]
+	}
+
+	if i := strings.LastIndexByte(key, '/'); i != -1 {
+		return key[:i], key[i+1:], client
+	}
+
+	return "", key, client
+}
+
+// SnapshotTags returns a copy of the message's tags which is safe to read or
+// serialize from another goroutine, even if the message's own tags are
+// modified afterwards. The snapshot must be taken before handing it off.
+func (m *Message) SnapshotTags() Tags {
+	return m.Tags.Copy()
+}
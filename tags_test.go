@@ -0,0 +1,342 @@
+package irc_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestTagsIsSetHasValue(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input       string
+		IsSet       bool
+		HasValue    bool
+		TagHasValue bool
+	}{
+		{
+			Input:       "@foo PING",
+			IsSet:       true,
+			HasValue:    false,
+			TagHasValue: false,
+		},
+		{
+			Input:       "@foo= PING",
+			IsSet:       true,
+			HasValue:    false,
+			TagHasValue: true,
+		},
+		{
+			Input:       "@foo=bar PING",
+			IsSet:       true,
+			HasValue:    true,
+			TagHasValue: true,
+		},
+		{
+			Input:       "@foobar=;foo PING",
+			IsSet:       true,
+			HasValue:    false,
+			TagHasValue: false,
+		},
+		{
+			// The last copy of a tag wins
+			Input:       "@foo=;foo PING",
+			IsSet:       true,
+			HasValue:    false,
+			TagHasValue: false,
+		},
+		{
+			Input:       "@foo;foo= PING",
+			IsSet:       true,
+			HasValue:    false,
+			TagHasValue: true,
+		},
+		{
+			Input:       "@bar=baz PING",
+			IsSet:       false,
+			HasValue:    false,
+			TagHasValue: false,
+		},
+		{
+			Input:       "PING",
+			IsSet:       false,
+			HasValue:    false,
+			TagHasValue: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		m := irc.MustParseMessage(testCase.Input)
+		assert.Equal(t, testCase.IsSet, m.Tags.IsSet("foo"), "IsSet didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.HasValue, m.Tags.HasValue("foo"), "HasValue didn't match for %q", testCase.Input)
+		assert.Equal(t, testCase.TagHasValue, m.TagHasValue("foo"), "TagHasValue didn't match for %q", testCase.Input)
+
+		// Unmodified messages should always be written back in their
+		// original form.
+		assert.Equal(t, testCase.Input, m.String())
+	}
+
+	// Both forms survive other tags being modified.
+	m := irc.MustParseMessage("@foo=;empty PING")
+	m.Tags["bar"] = "baz"
+	assert.Equal(t, "@bar=baz;empty;foo= PING", m.String())
+	assert.True(t, m.TagHasValue("foo"))
+	assert.False(t, m.TagHasValue("empty"))
+
+	m = irc.MustParseMessage(m.String())
+	assert.Equal(t, "@bar=baz;empty;foo= PING", m.String())
+	assert.True(t, m.TagHasValue("foo"))
+	assert.False(t, m.TagHasValue("empty"))
+
+	// Copies keep the original form too.
+	m = m.Copy()
+	delete(m.Tags, "bar")
+	assert.Equal(t, "@empty;foo= PING", m.String())
+
+	// Once the tag is given a value, that's used instead.
+	m.Tags["foo"] = "bar"
+	assert.Equal(t, "@empty;foo=bar PING", m.String())
+	assert.True(t, m.TagHasValue("foo"))
+
+	// Messages which weren't parsed have no way to send @foo=.
+	m = &irc.Message{Tags: irc.Tags{"foo": ""}, Command: "PING"}
+	assert.Equal(t, "@foo PING", m.String())
+	assert.False(t, m.TagHasValue("foo"))
+}
+
+func TestTagsClient(t *testing.T) {
+	t.Parallel()
+
+	m := &irc.Message{
+		Tags:    irc.Tags{},
+		Command: "TAGMSG",
+		Params:  []string{"#chan"},
+	}
+	m.Tags.SetClient("typing", "active")
+	assert.Equal(t, "@+typing=active TAGMSG #chan", m.String())
+
+	value, ok := m.Tags.GetClient("typing")
+	assert.True(t, ok)
+	assert.Equal(t, "active", value)
+
+	// A leading + shouldn't be doubled
+	value, ok = m.Tags.GetClient("+typing")
+	assert.True(t, ok)
+	assert.Equal(t, "active", value)
+
+	m.Tags.SetClient("+typing", "paused")
+	assert.Equal(t, irc.Tags{"+typing": "paused"}, m.Tags)
+
+	// Server tags with the same name aren't client tags
+	m = irc.MustParseMessage("@draft/channel-context=#other :nick!user@host PRIVMSG lemuria :hi")
+	_, ok = m.Tags.GetClient("draft/channel-context")
+	assert.False(t, ok)
+
+	// Values are escaped when written out
+	m = &irc.Message{Tags: irc.Tags{}, Command: "PRIVMSG", Params: []string{"lemuria", "hi"}}
+	m.Tags.SetClient("draft/channel-context", "#chan; with spaces")
+	assert.Equal(t, `@+draft/channel-context=#chan\:\swith\sspaces PRIVMSG lemuria hi`, m.String())
+
+	m = irc.MustParseMessage(m.String())
+	value, ok = m.Tags.GetClient("draft/channel-context")
+	assert.True(t, ok)
+	assert.Equal(t, "#chan; with spaces", value)
+
+	// Client tags can be added to parsed messages without any tags
+	m = irc.MustParseMessage("PRIVMSG lemuria :hi")
+	_, ok = m.Tags.GetClient("typing")
+	assert.False(t, ok)
+	m.Tags.SetClient("draft/channel-context", "#chan")
+	m.Tags.SetClient("typing", "active")
+	assert.Equal(t, "@+draft/channel-context=#chan;+typing=active PRIVMSG lemuria hi", m.String())
+}
+
+func TestTagsKeys(t *testing.T) {
+	t.Parallel()
+
+	tags := irc.Tags{"time": "now", "+draft/reply": "abc", "account": "", "msgid": "123"}
+	assert.Equal(t, []string{"+draft/reply", "account", "msgid", "time"}, tags.Keys())
+
+	assert.Empty(t, irc.Tags{}.Keys())
+	assert.Empty(t, irc.MustParseMessage("PING").Tags.Keys())
+}
+
+func TestTagsRange(t *testing.T) {
+	t.Parallel()
+
+	tags := irc.Tags{"a": "1", "b": "2", "c": ""}
+
+	seen := irc.Tags{}
+	tags.Range(func(k, v string) bool {
+		seen[k] = v
+		return true
+	})
+	assert.Equal(t, tags, seen)
+
+	// Returning false should stop after the first tag
+	calls := 0
+	tags.Range(func(k, v string) bool {
+		calls++
+		return false
+	})
+	assert.Equal(t, 1, calls)
+
+	// Nil tags have nothing to iterate over
+	irc.MustParseMessage("PING").Tags.Range(func(k, v string) bool {
+		t.Error("Range called fn for nil tags")
+		return true
+	})
+}
+
+func TestTagSortModes(t *testing.T) {
+	t.Parallel()
+
+	tags := irc.Tags{
+		"+typing": "active",
+		"time":    "2022-01-01T00:00:00.000Z",
+		"+draft":  "",
+		"account": "lemuria",
+	}
+
+	assert.Equal(t, "+draft;+typing=active;account=lemuria;time=2022-01-01T00:00:00.000Z", tags.StringSorted(irc.TagSortAlphabetical))
+	assert.Equal(t, "account=lemuria;time=2022-01-01T00:00:00.000Z;+draft;+typing=active", tags.StringSorted(irc.TagSortServerFirst))
+	assert.Len(t, tags.StringSorted(irc.TagSortNone), len(tags.StringSorted(irc.TagSortAlphabetical)))
+
+	// An explicit sort mode on a message overrides the original tag order.
+	m := irc.MustParseMessage("@+typing=active;time=2022-01-01T00:00:00.000Z PING")
+	assert.Equal(t, "@+typing=active;time=2022-01-01T00:00:00.000Z PING", m.String())
+
+	m.SortTags = irc.TagSortServerFirst
+	assert.Equal(t, "@time=2022-01-01T00:00:00.000Z;+typing=active PING", m.String())
+}
+
+func TestTagsMerge(t *testing.T) {
+	t.Parallel()
+
+	other := irc.Tags{"a": "other", "c": "3", "d": ""}
+
+	tags := irc.Tags{"a": "1", "b": "2"}
+	tags.Merge(other, false)
+	assert.Equal(t, irc.Tags{"a": "1", "b": "2", "c": "3", "d": ""}, tags)
+
+	tags = irc.Tags{"a": "1", "b": "2"}
+	tags.Merge(other, true)
+	assert.Equal(t, irc.Tags{"a": "other", "b": "2", "c": "3", "d": ""}, tags)
+
+	// An empty value still counts as being set
+	tags = irc.Tags{"d": ""}
+	tags.Merge(irc.Tags{"d": "4"}, false)
+	assert.Equal(t, irc.Tags{"d": ""}, tags)
+
+	// Merging nothing into nil tags is fine
+	var empty irc.Tags
+	assert.NotPanics(t, func() {
+		empty.Merge(nil, true)
+	})
+
+	// The other tags shouldn't be modified
+	assert.Equal(t, irc.Tags{"a": "other", "c": "3", "d": ""}, other)
+}
+
+func TestEscapedTagLen(t *testing.T) {
+	t.Parallel()
+
+	for _, value := range []string{
+		"",
+		"plain",
+		"a;b;c;d",
+		"; ; ; ;",
+		"\\\\",
+		"line one\r\nline two",
+		"ünïcödé",
+		"invalid \xff utf8",
+	} {
+		assert.Equal(t, len(irc.EncodeTagValue(value)), irc.EscapedTagLen(value), "length didn't match for %q", value)
+	}
+
+	assert.Equal(t, 5, irc.EscapedTagLen("plain"))
+	assert.Equal(t, 14, irc.EscapedTagLen("; ; ; ;"))
+}
+
+func TestSplitTagKey(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Key    string
+		Vendor string
+		Name   string
+		Client bool
+	}{
+		{Key: "+example.com/foo", Vendor: "example.com", Name: "foo", Client: true},
+		{Key: "example.com/foo", Vendor: "example.com", Name: "foo", Client: false},
+		{Key: "+foo", Vendor: "", Name: "foo", Client: true},
+		{Key: "foo", Vendor: "", Name: "foo", Client: false},
+		{Key: "+draft/reply", Vendor: "draft", Name: "reply", Client: true},
+	}
+
+	for _, testCase := range testCases {
+		vendor, name, client := irc.SplitTagKey(testCase.Key)
+		assert.Equal(t, testCase.Vendor, vendor, "Vendor didn't match for %q", testCase.Key)
+		assert.Equal(t, testCase.Name, name, "Name didn't match for %q", testCase.Key)
+		assert.Equal(t, testCase.Client, client, "Client didn't match for %q", testCase.Key)
+	}
+}
+
+func TestMessageSnapshotTags(t *testing.T) {
+	t.Parallel()
+
+	m := irc.MustParseMessage("@a=1;b=2 PRIVMSG #chan :hello")
+
+	snapshot := m.SnapshotTags()
+	assert.Equal(t, m.Tags, snapshot)
+
+	// Serializing the snapshot while the original is modified shouldn't be
+	// caught by the race detector.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "a=1;b=2", snapshot.StringSorted(irc.TagSortAlphabetical))
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		m.Tags["c"] = strconv.Itoa(i)
+	}
+
+	wg.Wait()
+
+	assert.False(t, snapshot.IsSet("c"))
+}
+
+func TestMessageMsgID(t *testing.T) {
+	t.Parallel()
+
+	id, ok := irc.MustParseMessage("@msgid=abc123 PING").MsgID()
+	assert.True(t, ok)
+	assert.Equal(t, "abc123", id)
+
+	_, ok = irc.MustParseMessage("@msgid= PING").MsgID()
+	assert.False(t, ok)
+
+	_, ok = irc.MustParseMessage("PING").MsgID()
+	assert.False(t, ok)
+}
+
+func TestMessageIsFromBot(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, irc.MustParseMessage("@bot :bot!bot@host PRIVMSG #chan :beep").IsFromBot())
+	assert.True(t, irc.MustParseMessage("@bot= :bot!bot@host PRIVMSG #chan :beep").IsFromBot())
+	assert.True(t, irc.MustParseMessage("@draft/bot;msgid=abc :bot!bot@host PRIVMSG #chan :beep").IsFromBot())
+
+	assert.False(t, irc.MustParseMessage("@msgid=abc :nick!user@host PRIVMSG #chan :hello").IsFromBot())
+	assert.False(t, irc.MustParseMessage("@+bot :nick!user@host PRIVMSG #chan :hello").IsFromBot())
+	assert.False(t, irc.MustParseMessage(":nick!user@host PRIVMSG #chan :hello").IsFromBot())
+}
@@ -0,0 +1,77 @@
+package irc
+
+import "strings"
+
+// SplitStatusMsg splits any STATUSMSG prefixes off of a message target. As an
+// example, with STATUSMSG=@+, a target of "@#channel" means the message was
+// only sent to channel operators, so this would return "@" and "#channel". If
+// there are no status prefixes, an empty prefix and the original target are
+// returned.
+func SplitStatusMsg(target string, supports ISupport) (statusPrefix string, channel string) {
+	statusChars := supports["STATUSMSG"]
+	if statusChars == "" {
+		return "", target
+	}
+
+	i := strings.IndexFunc(target, func(r rune) bool {
+		return !strings.ContainsRune(statusChars, r)
+	})
+
+	// If the target is nothing but status characters, there is no channel to
+	// split off, so we leave it alone.
+	if i <= 0 {
+		return "", target
+	}
+
+	return target[:i], target[i:]
+}
+
+// ReplyTarget returns where a reply to this message should be sent. If the
+// message was sent directly to myNick, this is the nick of the sender,
+// otherwise it is the channel the message was sent to, with any STATUSMSG
+// prefixes removed. Nicks are compared using the server's CASEMAPPING. An
+// empty string is returned if the message has no target.
+func (m *Message) ReplyTarget(myNick string, supports ISupport) string {
+	if len(m.Params) < 1 {
+		return ""
+	}
+
+	target := m.Params[0]
+	if NickEqual(target, myNick, supports.CaseMapping()) {
+		if m.Prefix == nil {
+			return ""
+		}
+
+		return m.Prefix.Name
+	}
+
+	_, channel := SplitStatusMsg(target, supports)
+
+	return channel
+}
+
+// IsDirectedAtMe returns true if myNick is the target of this message, rather
+// than a channel or another user. Nicks are compared using the server's
+// CASEMAPPING. The commands considered are:
+//
+//   - PRIVMSG, NOTICE and TAGMSG sent directly to us
+//   - INVITE, when we are the one being invited
+//   - KICK, when we are the one being kicked
+//   - MODE, when our own user modes are changed
+//
+// Numerics always have our nick as their first param, so they are never
+// considered directed at us, and neither is any other command.
+func (m *Message) IsDirectedAtMe(myNick string, supports ISupport) bool {
+	var target string
+
+	switch m.Command {
+	case "PRIVMSG", "NOTICE", "TAGMSG", "INVITE", "MODE":
+		target = m.Param(0)
+	case "KICK":
+		target = m.Param(1)
+	default:
+		return false
+	}
+
+	return target != "" && NickEqual(target, myNick, supports.CaseMapping())
+}
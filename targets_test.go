@@ -0,0 +1,169 @@
+package irc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestSplitStatusMsg(t *testing.T) {
+	t.Parallel()
+
+	supports := irc.ISupport{"STATUSMSG": "@+"}
+
+	var testCases = []struct { //nolint:gofumpt
+		Target   string
+		Supports irc.ISupport
+		Prefix   string
+		Channel  string
+	}{
+		{
+			Target:   "@#channel",
+			Supports: supports,
+			Prefix:   "@",
+			Channel:  "#channel",
+		},
+		{
+			Target:   "@+#channel",
+			Supports: supports,
+			Prefix:   "@+",
+			Channel:  "#channel",
+		},
+		{
+			Target:   "#channel",
+			Supports: supports,
+			Prefix:   "",
+			Channel:  "#channel",
+		},
+		{
+			Target:   "nick",
+			Supports: supports,
+			Prefix:   "",
+			Channel:  "nick",
+		},
+		{ // Not a status char on this server
+			Target:   "%#channel",
+			Supports: supports,
+			Prefix:   "",
+			Channel:  "%#channel",
+		},
+		{ // No STATUSMSG at all
+			Target:   "@#channel",
+			Supports: nil,
+			Prefix:   "",
+			Channel:  "@#channel",
+		},
+		{
+			Target:   "@",
+			Supports: supports,
+			Prefix:   "",
+			Channel:  "@",
+		},
+	}
+
+	for _, testCase := range testCases {
+		prefix, channel := irc.SplitStatusMsg(testCase.Target, testCase.Supports)
+		assert.Equal(t, testCase.Prefix, prefix, "Prefix didn't match for %q", testCase.Target)
+		assert.Equal(t, testCase.Channel, channel, "Channel didn't match for %q", testCase.Target)
+	}
+}
+
+func TestMessageReplyTarget(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input    string
+		MyNick   string
+		Supports irc.ISupport
+		Expect   string
+	}{
+		{
+			Input:  ":nick!user@host PRIVMSG #chan :hello",
+			MyNick: "lemuria",
+			Expect: "#chan",
+		},
+		{
+			Input:  ":nick!user@host PRIVMSG lemuria :hello",
+			MyNick: "lemuria",
+			Expect: "nick",
+		},
+		{
+			// The default casemapping treats [] and {} as the same
+			Input:  ":nick!user@host PRIVMSG LEMURIA{} :hello",
+			MyNick: "lemuria[]",
+			Expect: "nick",
+		},
+		{
+			Input:    ":nick!user@host PRIVMSG LEMURIA{} :hello",
+			MyNick:   "lemuria[]",
+			Supports: irc.ISupport{"CASEMAPPING": "ascii"},
+			Expect:   "LEMURIA{}",
+		},
+		{
+			Input:    ":nick!user@host PRIVMSG @#chan :hello",
+			MyNick:   "lemuria",
+			Supports: irc.ISupport{"STATUSMSG": "@+"},
+			Expect:   "#chan",
+		},
+		{
+			Input:  ":nick!user@host PRIVMSG",
+			MyNick: "lemuria",
+			Expect: "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		target := irc.MustParseMessage(testCase.Input).ReplyTarget(testCase.MyNick, testCase.Supports)
+		assert.Equal(t, testCase.Expect, target, "target didn't match for %q", testCase.Input)
+	}
+}
+
+func TestMessageIsDirectedAtMe(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Input    string
+		MyNick   string
+		Supports irc.ISupport
+		Expect   bool
+	}{
+		{Input: ":nick!user@host PRIVMSG lemuria :hello", Expect: true},
+		{Input: ":nick!user@host PRIVMSG #chan :hello lemuria", Expect: false},
+		{Input: ":nick!user@host PRIVMSG someone :hello", Expect: false},
+		{Input: ":irc.example.com NOTICE lemuria :*** Looking up your hostname", Expect: true},
+		{Input: "@+typing=active :nick!user@host TAGMSG lemuria", Expect: true},
+		{Input: ":nick!user@host INVITE lemuria #chan", Expect: true},
+		{Input: ":nick!user@host INVITE someone #chan", Expect: false},
+		{Input: ":nick!user@host KICK #chan lemuria :bye", Expect: true},
+		{Input: ":nick!user@host KICK #chan someone :bye", Expect: false},
+		{Input: ":lemuria MODE lemuria :+i", Expect: true},
+		{Input: ":nick!user@host MODE #chan +o lemuria", Expect: false},
+		{Input: ":irc.example.com 001 lemuria :Welcome", Expect: false},
+		{Input: ":nick!user@host NICK lemuria", Expect: false},
+		{Input: ":nick!user@host PRIVMSG", Expect: false},
+		{
+			// The default casemapping treats [] and {} as the same
+			Input:  ":nick!user@host PRIVMSG LEMURIA{} :hello",
+			MyNick: "lemuria[]",
+			Expect: true,
+		},
+		{
+			Input:    ":nick!user@host PRIVMSG LEMURIA{} :hello",
+			MyNick:   "lemuria[]",
+			Supports: irc.ISupport{"CASEMAPPING": "ascii"},
+			Expect:   false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		myNick := testCase.MyNick
+		if myNick == "" {
+			myNick = "lemuria"
+		}
+
+		ok := irc.MustParseMessage(testCase.Input).IsDirectedAtMe(myNick, testCase.Supports)
+		assert.Equal(t, testCase.Expect, ok, "IsDirectedAtMe didn't match for %q", testCase.Input)
+	}
+}
@@ -2,9 +2,15 @@ package irc
 
 import (
 	"bytes"
+	"errors"
 	"regexp"
+	"strings"
 )
 
+// ErrInvalidMask is returned by MaskCovers if a mask is empty or contains a
+// space.
+var ErrInvalidMask = errors.New("irc: invalid mask")
+
 var maskTranslations = map[byte]string{
 	'?': ".",
 	'*': ".*",
@@ -48,3 +54,94 @@ func MaskToRegex(rawMask string) (*regexp.Regexp, error) {
 
 	return regexp.Compile(output.String())
 }
+
+// maskToken is a single element of a mask. If wildcard is 0, the token matches
+// the literal rune r, otherwise it is '?' or '*'.
+type maskToken struct {
+	wildcard byte
+	r        rune
+}
+
+// tokenizeMask splits a mask into tokens, handling escapes the same way as
+// MaskToRegex. Literals are folded with the given mapping.
+func tokenizeMask(mask string, mapping CaseMapping) []maskToken {
+	var ret []maskToken
+
+	literal := func(s string) {
+		for _, r := range mapping.ToLower(s) {
+			ret = append(ret, maskToken{r: r})
+		}
+	}
+
+	runes := []rune(mask)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '\\' && i+1 < len(runes) && strings.ContainsRune("?*\\", runes[i+1]):
+			literal(string(runes[i+1]))
+			i++
+		case r == '?' || r == '*':
+			ret = append(ret, maskToken{wildcard: byte(r)})
+		default:
+			literal(string(r))
+		}
+	}
+
+	return ret
+}
+
+// MaskCovers checks if every nick!user@host matched by the narrow mask would
+// also be matched by the broad mask, such as "*!*@host" covering
+// "nick!*@host". This can be used to avoid setting bans which are already
+// covered by an existing ban. Masks are compared using the given CaseMapping.
+//
+// This is an approximation: a '*' in narrow is only considered covered by a '*'
+// in broad, and a '?' in narrow by a '?' or '*' in broad. It will never report
+// that a mask is covered when it isn't, but some unusual pairs, like "?*"
+// and "*?", will be reported as not covered even though they match the same
+// things.
+func MaskCovers(broad, narrow string, mapping CaseMapping) (bool, error) {
+	if broad == "" || narrow == "" || strings.ContainsRune(broad, ' ') || strings.ContainsRune(narrow, ' ') {
+		return false, ErrInvalidMask
+	}
+
+	b := tokenizeMask(broad, mapping)
+	n := tokenizeMask(narrow, mapping)
+
+	// memo[i][j] caches whether b[i:] covers n[j:]. 0 means it hasn't been
+	// checked yet, 1 means no and 2 means yes.
+	memo := make([][]byte, len(b)+1)
+	for i := range memo {
+		memo[i] = make([]byte, len(n)+1)
+	}
+
+	var covers func(i, j int) bool
+	covers = func(i, j int) bool {
+		if memo[i][j] != 0 {
+			return memo[i][j] == 2
+		}
+
+		var ret bool
+		switch {
+		case i == len(b):
+			ret = j == len(n)
+		case b[i].wildcard == '*':
+			// The star can match nothing, or swallow the next token.
+			ret = covers(i+1, j) || (j < len(n) && covers(i, j+1))
+		case j == len(n):
+			ret = false
+		case b[i].wildcard == '?':
+			ret = n[j].wildcard != '*' && covers(i+1, j+1)
+		default:
+			ret = n[j].wildcard == 0 && n[j].r == b[i].r && covers(i+1, j+1)
+		}
+
+		memo[i][j] = 1
+		if ret {
+			memo[i][j] = 2
+		}
+
+		return ret
+	}
+
+	return covers(0, 0), nil
+}
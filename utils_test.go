@@ -63,3 +63,54 @@ func TestMaskToRegex(t *testing.T) {
 		assert.Equal(t, testCase.Expect, ret.String())
 	}
 }
+
+func TestMaskCovers(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Broad  string
+		Narrow string
+		Expect bool
+	}{
+		{Broad: "*!*@host", Narrow: "nick!*@host", Expect: true},
+		{Broad: "*!*@host", Narrow: "nick!user@host", Expect: true},
+		{Broad: "*!*@*.example.com", Narrow: "*!*@user.example.com", Expect: true},
+		{Broad: "*!*@*", Narrow: "*!*@*", Expect: true},
+		{Broad: "*", Narrow: "*!*@*", Expect: true},
+		{Broad: "nick!*@*", Narrow: "nick!?ser@host", Expect: true},
+		{Broad: "n?ck!*@*", Narrow: "nick!user@host", Expect: true},
+		{Broad: "n?ck!*@*", Narrow: "n?ck!user@host", Expect: true},
+
+		// Case mapping
+		{Broad: "*!*@HOST", Narrow: "Nick[]!*@host", Expect: true},
+		{Broad: "nick{}!*@*", Narrow: "NICK[]!*@host", Expect: true},
+
+		// Escaped wildcards only match themselves
+		{Broad: "a\\*b!*@*", Narrow: "a*b!*@*", Expect: false},
+		{Broad: "a\\*b!*@*", Narrow: "a\\*b!*@*", Expect: true},
+		{Broad: "a*b!*@*", Narrow: "a\\*b!*@*", Expect: true},
+		{Broad: "a\\\\b!*@*", Narrow: "a|b!*@*", Expect: true},
+		{Broad: "a\\b!*@*", Narrow: "a|b!*@*", Expect: true},
+
+		// Narrower broad masks
+		{Broad: "nick!*@host", Narrow: "*!*@host", Expect: false},
+		{Broad: "nick!user@host", Narrow: "nick!*@host", Expect: false},
+		{Broad: "n?ck!*@*", Narrow: "n*ck!*@*", Expect: false},
+
+		// Disjoint
+		{Broad: "*!*@host", Narrow: "*!*@other", Expect: false},
+		{Broad: "*!*@*.example.com", Narrow: "*!*@example.org", Expect: false},
+	}
+
+	for _, testCase := range testCases {
+		covers, err := irc.MaskCovers(testCase.Broad, testCase.Narrow, irc.CaseMappingRFC1459)
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.Expect, covers, "%q covering %q didn't match", testCase.Broad, testCase.Narrow)
+	}
+
+	_, err := irc.MaskCovers("", "*!*@*", irc.CaseMappingRFC1459)
+	assert.Equal(t, irc.ErrInvalidMask, err)
+
+	_, err = irc.MaskCovers("*!*@*", "a b", irc.CaseMappingRFC1459)
+	assert.Equal(t, irc.ErrInvalidMask, err)
+}
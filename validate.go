@@ -0,0 +1,202 @@
+package irc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// These defaults from RFC 1459 are used when the server doesn't send the
+// matching ISUPPORT tokens.
+const (
+	defaultNickLen    = 9
+	defaultChannelLen = 200
+	defaultChanTypes  = "#&"
+)
+
+var (
+	// ErrNickEmpty is returned by ValidNick for an empty nick.
+	ErrNickEmpty = errors.New("irc: nick is empty")
+
+	// ErrNickTooLong is returned by ValidNick if the nick is longer than
+	// NICKLEN.
+	ErrNickTooLong = errors.New("irc: nick is too long")
+
+	// ErrNickInvalidStart is returned by ValidNick if the nick starts with a
+	// character which is only allowed later on, such as a digit.
+	ErrNickInvalidStart = errors.New("irc: nick starts with an invalid character")
+
+	// ErrNickInvalidChar is returned by ValidNick if the nick contains a
+	// character which is never allowed, such as a space.
+	ErrNickInvalidChar = errors.New("irc: nick contains an invalid character")
+
+	// ErrChannelInvalidPrefix is returned by ValidChannel if the name doesn't
+	// start with one of the CHANTYPES.
+	ErrChannelInvalidPrefix = errors.New("irc: channel doesn't start with a channel type")
+
+	// ErrChannelTooLong is returned by ValidChannel if the name is longer
+	// than CHANNELLEN.
+	ErrChannelTooLong = errors.New("irc: channel is too long")
+
+	// ErrChannelInvalidChar is returned by ValidChannel if the name
+	// contains a character which is never allowed, such as a space.
+	ErrChannelInvalidChar = errors.New("irc: channel contains an invalid character")
+)
+
+// ValidNick checks if nick is likely to be accepted by the server before
+// sending it in a NICK command. The length is checked in bytes against
+// NICKLEN, falling back to the RFC 1459 limit of 9 if it is missing. Nicks may
+// not start with a digit, '-', or any of the characters used for channels or
+// trailing params, and may not contain spaces, control characters, invalid
+// UTF-8, or any of ,*?!@. as these have special meaning in masks and targets.
+//
+// The returned error wraps one of the ErrNick errors, so errors.Is can be used
+// to check which rule was broken. Servers may have stricter rules, so a nil
+// error doesn't guarantee the nick will be accepted.
+func ValidNick(nick string, supports ISupport) error {
+	if nick == "" {
+		return ErrNickEmpty
+	}
+
+	if maxLen := supports.intValue("NICKLEN", defaultNickLen); len(nick) > maxLen {
+		return fmt.Errorf("%w: %d bytes, maximum is %d", ErrNickTooLong, len(nick), maxLen)
+	}
+
+	if first := nick[0]; isDigit(first) || strings.IndexByte("-#&:$+~%", first) != -1 {
+		return fmt.Errorf("%w: %q", ErrNickInvalidStart, first)
+	}
+
+	for i, r := range nick {
+		if r == utf8.RuneError || r < ' ' || r == 0x7f || strings.ContainsRune(" ,*?!@.", r) {
+			return fmt.Errorf("%w: %q at byte %d", ErrNickInvalidChar, r, i)
+		}
+	}
+
+	return nil
+}
+
+// ValidChannel checks if name is a valid channel name before sending it in a
+// command such as JOIN. It must start with one of the CHANTYPES (# or & if
+// the server didn't send any), be no longer than CHANNELLEN bytes (200 by
+// default), and not contain spaces, commas or control characters, including
+// BEL (\x07).
+//
+// The returned error wraps one of the ErrChannel errors, so errors.Is can be
+// used to check which rule was broken.
+func ValidChannel(name string, supports ISupport) error {
+	chanTypes, ok := supports["CHANTYPES"]
+	if !ok {
+		chanTypes = defaultChanTypes
+	}
+
+	if name == "" || strings.IndexByte(chanTypes, name[0]) == -1 {
+		return fmt.Errorf("%w: %q must start with one of %q", ErrChannelInvalidPrefix, name, chanTypes)
+	}
+
+	if maxLen := supports.intValue("CHANNELLEN", defaultChannelLen); len(name) > maxLen {
+		return fmt.Errorf("%w: %d bytes, maximum is %d", ErrChannelTooLong, len(name), maxLen)
+	}
+
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; c <= ' ' || c == ',' || c == 0x7f {
+			return fmt.Errorf("%w: %q at byte %d", ErrChannelInvalidChar, c, i)
+		}
+	}
+
+	return nil
+}
+
+// SuggestNick suggests another nick to try after the server rejects one with
+// ERR_NICKNAMEINUSE (433) or ERR_ERRONEUSNICKNAME (432). The rejected nick
+// should be the one originally wanted, with attempt counting up from 1 for
+// each rejection. The first attempt adds a '_', and later attempts add the
+// attempt number instead, so "nick" becomes "nick_", then "nick2", "nick3" and
+// so on.
+//
+// The base nick is truncated as needed so the suggestion fits in NICKLEN,
+// falling back to the RFC 1459 limit of 9 if it is missing. The suggestion is
+// never longer than NICKLEN, so if the suffix would leave no room for any of
+// the base nick, it is left off and only the truncated nick is returned. This
+// is also the case if attempt is zero or negative.
+func SuggestNick(rejected string, attempt int, supports ISupport) string {
+	var suffix string
+	switch {
+	case attempt == 1:
+		suffix = "_"
+	case attempt > 1:
+		suffix = strconv.Itoa(attempt)
+	}
+
+	maxLen := supports.intValue("NICKLEN", defaultNickLen)
+
+	if base := truncateNick(rejected, maxLen-len(suffix)); base != "" {
+		return base + suffix
+	}
+
+	return truncateNick(rejected, maxLen)
+}
+
+// truncateNick cuts nick down to at most maxLen bytes, without cutting a
+// multi-byte character in half.
+func truncateNick(nick string, maxLen int) string {
+	if maxLen <= 0 {
+		return ""
+	}
+
+	if len(nick) <= maxLen {
+		return nick
+	}
+
+	for maxLen > 0 && !utf8.RuneStart(nick[maxLen]) {
+		maxLen--
+	}
+
+	return nick[:maxLen]
+}
+
+// IsValid is a quick check that the message can be serialized into a single
+// line which will parse back into the same command and params. It is meant
+// for hot paths, such as before forwarding a message, so it doesn't allocate.
+//
+// The command must be non-empty and may not contain spaces or start with a
+// ':' or '@'. Every param other than the last must be non-empty, and may not
+// contain spaces or start with a ':', as it would otherwise be split up or
+// read as the trailing param. No param may contain a CR, LF or NUL.
+//
+// The tags and prefix aren't checked, and neither are line length limits or
+// the rules for specific commands, so a valid message may still be rejected
+// by the server.
+func (m *Message) IsValid() bool {
+	if m.Command == "" || m.Command[0] == ':' || m.Command[0] == '@' || strings.ContainsAny(m.Command, " \r\n\x00") {
+		return false
+	}
+
+	for i, param := range m.Params {
+		if strings.ContainsAny(param, "\r\n\x00") {
+			return false
+		}
+
+		if i == len(m.Params)-1 {
+			break
+		}
+
+		if param == "" || param[0] == ':' || strings.IndexByte(param, ' ') != -1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// intValue returns the value of an ISUPPORT token as a number, or def if it is
+// missing or not a valid positive number.
+func (s ISupport) intValue(key string, def int) int {
+	value, err := strconv.Atoi(s[key])
+	if err != nil || value <= 0 {
+		return def
+	}
+
+	return value
+}
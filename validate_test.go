@@ -0,0 +1,185 @@
+package irc_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestValidNick(t *testing.T) {
+	t.Parallel()
+
+	supports := irc.ISupport{"NICKLEN": "16"}
+
+	var testCases = []struct { //nolint:gofumpt
+		Nick     string
+		Supports irc.ISupport
+		Err      error
+	}{
+		{Nick: "lemuria"},
+		{Nick: "lemuria[away]", Supports: supports},
+		{Nick: "Lemuria_|`^{}\\", Supports: supports},
+		{Nick: "ľemúria", Supports: supports},
+		{Nick: "", Err: irc.ErrNickEmpty},
+		{Nick: "lemuria_is_long", Err: irc.ErrNickTooLong},
+		{Nick: "lemuria_is_long", Supports: supports},
+		{Nick: "lemuria_is_far_too_long", Supports: supports, Err: irc.ErrNickTooLong},
+		{Nick: "ľemúriaľemúria", Supports: supports, Err: irc.ErrNickTooLong},
+		{Nick: "1lemuria", Err: irc.ErrNickInvalidStart},
+		{Nick: "-lemuria", Err: irc.ErrNickInvalidStart},
+		{Nick: "#lemuria", Err: irc.ErrNickInvalidStart},
+		{Nick: ":lemuria", Err: irc.ErrNickInvalidStart},
+		{Nick: "lemuria1-"},
+		{Nick: "lem uria", Err: irc.ErrNickInvalidChar},
+		{Nick: "lem,uria", Err: irc.ErrNickInvalidChar},
+		{Nick: "lem!uria", Err: irc.ErrNickInvalidChar},
+		{Nick: "lem@uria", Err: irc.ErrNickInvalidChar},
+		{Nick: "lem.uria", Err: irc.ErrNickInvalidChar},
+		{Nick: "lem*uria", Err: irc.ErrNickInvalidChar},
+		{Nick: "lem\x02uria", Err: irc.ErrNickInvalidChar},
+		{Nick: "lem\xffuria", Err: irc.ErrNickInvalidChar},
+	}
+
+	for _, testCase := range testCases {
+		err := irc.ValidNick(testCase.Nick, testCase.Supports)
+		if testCase.Err == nil {
+			assert.NoError(t, err, "%q should be valid", testCase.Nick)
+		} else {
+			assert.ErrorIs(t, err, testCase.Err, "%q should be invalid", testCase.Nick)
+		}
+	}
+}
+
+func TestValidChannel(t *testing.T) {
+	t.Parallel()
+
+	supports := irc.ISupport{"CHANTYPES": "#", "CHANNELLEN": "10"}
+
+	var testCases = []struct { //nolint:gofumpt
+		Name     string
+		Supports irc.ISupport
+		Err      error
+	}{
+		{Name: "#lemuria"},
+		{Name: "&lemuria"},
+		{Name: "#lemuria", Supports: supports},
+		{Name: "#ľemúria"},
+		{Name: "##lemuria"},
+		{Name: "", Err: irc.ErrChannelInvalidPrefix},
+		{Name: "lemuria", Err: irc.ErrChannelInvalidPrefix},
+		{Name: "&lemuria", Supports: supports, Err: irc.ErrChannelInvalidPrefix},
+		{Name: "#lemuria", Supports: irc.ISupport{"CHANTYPES": ""}, Err: irc.ErrChannelInvalidPrefix},
+		{Name: "#lemuria_is_long", Supports: supports, Err: irc.ErrChannelTooLong},
+		{Name: "#" + strings.Repeat("a", 200), Err: irc.ErrChannelTooLong},
+		{Name: "#lem uria", Err: irc.ErrChannelInvalidChar},
+		{Name: "#lem,uria", Err: irc.ErrChannelInvalidChar},
+		{Name: "#lem\x07uria", Err: irc.ErrChannelInvalidChar},
+		{Name: "#lem\x00uria", Err: irc.ErrChannelInvalidChar},
+	}
+
+	for _, testCase := range testCases {
+		err := irc.ValidChannel(testCase.Name, testCase.Supports)
+		if testCase.Err == nil {
+			assert.NoError(t, err, "%q should be valid", testCase.Name)
+		} else {
+			assert.ErrorIs(t, err, testCase.Err, "%q should be invalid", testCase.Name)
+		}
+	}
+}
+
+func TestSuggestNick(t *testing.T) {
+	t.Parallel()
+
+	var suggestions []string
+	for attempt := 0; attempt <= 3; attempt++ {
+		suggestions = append(suggestions, irc.SuggestNick("lemuria", attempt, nil))
+	}
+	assert.Equal(t, []string{"lemuria", "lemuria_", "lemuria2", "lemuria3"}, suggestions)
+
+	var testCases = []struct { //nolint:gofumpt
+		Rejected string
+		Attempt  int
+		Supports irc.ISupport
+		Expected string
+	}{
+		// The default NICKLEN is 9
+		{Rejected: "lemurian", Attempt: 1, Expected: "lemurian_"},
+		{Rejected: "lemurian", Attempt: 2, Expected: "lemurian2"},
+		{Rejected: "lemuriann", Attempt: 1, Expected: "lemurian_"},
+		{Rejected: "lemuriann", Attempt: 12, Expected: "lemuria12"},
+		{Rejected: "lemurian_is_long", Attempt: 0, Expected: "lemurian_"},
+		{Rejected: "lemurian_is_long", Attempt: 1, Supports: irc.ISupport{"NICKLEN": "30"}, Expected: "lemurian_is_long_"},
+		{Rejected: "lemurian", Attempt: 3, Supports: irc.ISupport{"NICKLEN": "5"}, Expected: "lemu3"},
+		// Multi-byte characters shouldn't be split
+		{Rejected: "lemuriaľ", Attempt: 1, Expected: "lemuria_"},
+		// The suffix is dropped if there's no room for it
+		{Rejected: "lemuria", Attempt: 1, Supports: irc.ISupport{"NICKLEN": "1"}, Expected: "l"},
+		{Rejected: "lemuria", Attempt: 12, Supports: irc.ISupport{"NICKLEN": "2"}, Expected: "le"},
+		{Rejected: "lemuria", Attempt: 12, Supports: irc.ISupport{"NICKLEN": "3"}, Expected: "l12"},
+		{Rejected: "lemuria", Attempt: 1, Supports: irc.ISupport{"NICKLEN": "2"}, Expected: "l_"},
+		{Rejected: "ľemuria", Attempt: 1, Supports: irc.ISupport{"NICKLEN": "2"}, Expected: "ľ"},
+		{Rejected: "ľemuria", Attempt: 2, Supports: irc.ISupport{"NICKLEN": "3"}, Expected: "ľ2"},
+		{Rejected: "ľemuria", Attempt: 12, Supports: irc.ISupport{"NICKLEN": "2"}, Expected: "ľ"},
+	}
+
+	for _, testCase := range testCases {
+		suggestion := irc.SuggestNick(testCase.Rejected, testCase.Attempt, testCase.Supports)
+		assert.Equal(t, testCase.Expected, suggestion, "Suggestion didn't match for %q attempt %d", testCase.Rejected, testCase.Attempt)
+		assert.NoError(t, irc.ValidNick(suggestion, testCase.Supports), "Suggestion %q isn't valid", suggestion)
+	}
+}
+
+func TestMessageIsValid(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct { //nolint:gofumpt
+		Message *irc.Message
+		Valid   bool
+	}{
+		{
+			Message: irc.MustParseMessage("@a=b :nick!user@host PRIVMSG #chan :hello world"),
+			Valid:   true,
+		},
+		{
+			Message: &irc.Message{Command: "PING"},
+			Valid:   true,
+		},
+		{
+			// Only the trailing param may be empty or contain spaces
+			Message: &irc.Message{Command: "TOPIC", Params: []string{"#chan", ""}},
+			Valid:   true,
+		},
+		{
+			Message: &irc.Message{Command: "PRIVMSG", Params: []string{"#chan", ":) hi"}},
+			Valid:   true,
+		},
+		{
+			Message: &irc.Message{Command: "", Params: []string{"#chan"}},
+		},
+		{
+			Message: &irc.Message{Command: "PRIV MSG", Params: []string{"#chan", "hi"}},
+		},
+		{
+			Message: &irc.Message{Command: ":PRIVMSG", Params: []string{"#chan", "hi"}},
+		},
+		{
+			Message: &irc.Message{Command: "PRIVMSG", Params: []string{"#chan one", "hi"}},
+		},
+		{
+			Message: &irc.Message{Command: "PRIVMSG", Params: []string{":#chan", "hi"}},
+		},
+		{
+			Message: &irc.Message{Command: "PRIVMSG", Params: []string{"", "hi"}},
+		},
+		{
+			Message: &irc.Message{Command: "PRIVMSG", Params: []string{"#chan", "hi\r\nQUIT"}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.Valid, testCase.Message.IsValid(), "IsValid didn't match for %#v", testCase.Message)
+	}
+}
@@ -0,0 +1,103 @@
+package irc
+
+// WelcomeResult contains the information sent by the server in the 001-004
+// numerics when registration completes.
+type WelcomeResult struct {
+	// Nick is the nick the server accepted, from RPL_WELCOME. This may be
+	// different from the nick which was requested, such as if the server
+	// truncated it.
+	Nick string
+
+	// Message is the text of RPL_WELCOME.
+	Message string
+
+	// Created is the text of RPL_CREATED.
+	Created string
+
+	// Server, Version, UserModes and ChannelModes come from RPL_MYINFO.
+	// ChannelParamModes is optional and will be empty if the server didn't
+	// send it.
+	Server            string
+	Version           string
+	UserModes         string
+	ChannelModes      string
+	ChannelParamModes string
+}
+
+// WelcomeCollector accumulates the numerics sent when registration completes
+// into a single WelcomeResult. It is not safe for concurrent use.
+type WelcomeCollector struct {
+	result *WelcomeResult
+}
+
+// NewWelcomeCollector creates a new, empty WelcomeCollector.
+func NewWelcomeCollector() *WelcomeCollector {
+	return &WelcomeCollector{}
+}
+
+// Add needs to be called for each incoming message. All other messages will
+// be ignored. Once RPL_MYINFO is received, the collected result will be
+// returned and done will be true. RPL_ISUPPORT is sent afterwards, which can
+// be handled with an ISupportTracker.
+func (c *WelcomeCollector) Add(m *Message) (result *WelcomeResult, done bool) {
+	if len(m.Params) < 1 {
+		return nil, false
+	}
+
+	switch m.Command {
+	case RPL_WELCOME:
+		// Starting a new registration, such as after reconnecting, should
+		// drop anything collected before.
+		c.result = &WelcomeResult{
+			Nick:    m.Params[0],
+			Message: m.Trailing(),
+		}
+
+		// If we don't get RPL_MYINFO, the prefix is the next best thing.
+		if m.Prefix != nil {
+			c.result.Server = m.Prefix.Name
+		}
+	case RPL_CREATED:
+		c.get().Created = m.Trailing()
+	case RPL_MYINFO:
+		c.handleMyInfo(c.get(), m)
+
+		result = c.result
+		c.result = nil
+		return result, true
+	}
+
+	return nil, false
+}
+
+// From the Modern IRC docs
+//
+//	004    RPL_MYINFO
+//	       "<client> <servername> <version> <available user modes>
+//	       <available channel modes> [<channel modes with a parameter>]"
+func (c *WelcomeCollector) handleMyInfo(result *WelcomeResult, m *Message) {
+	if result.Nick == "" {
+		result.Nick = m.Params[0]
+	}
+
+	fields := []*string{
+		&result.Server,
+		&result.Version,
+		&result.UserModes,
+		&result.ChannelModes,
+		&result.ChannelParamModes,
+	}
+
+	for i, field := range fields {
+		if i+1 < len(m.Params) {
+			*field = m.Params[i+1]
+		}
+	}
+}
+
+func (c *WelcomeCollector) get() *WelcomeResult {
+	if c.result == nil {
+		c.result = &WelcomeResult{}
+	}
+	return c.result
+}
@@ -0,0 +1,59 @@
+package irc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestWelcomeCollector(t *testing.T) {
+	t.Parallel()
+
+	c := irc.NewWelcomeCollector()
+
+	lines := []string{
+		":irc.example.com NOTICE * :*** Looking up your hostname...",
+		":irc.example.com 001 lemuria_ :Welcome to the Example IRC Network lemuria_",
+		":irc.example.com 002 lemuria_ :Your host is irc.example.com, running version ircd-1.2.3",
+		":irc.example.com 003 lemuria_ :This server was created Mon Jan 1 2024 at 00:00:00 UTC",
+	}
+
+	for _, line := range lines {
+		result, done := c.Add(irc.MustParseMessage(line))
+		assert.Nil(t, result)
+		assert.False(t, done)
+	}
+
+	result, done := c.Add(irc.MustParseMessage(":irc.example.com 004 lemuria_ irc.example.com ircd-1.2.3 iowx beIklmnopstv bkloveI"))
+	assert.True(t, done)
+	assert.Equal(t, &irc.WelcomeResult{
+		Nick:              "lemuria_",
+		Message:           "Welcome to the Example IRC Network lemuria_",
+		Created:           "This server was created Mon Jan 1 2024 at 00:00:00 UTC",
+		Server:            "irc.example.com",
+		Version:           "ircd-1.2.3",
+		UserModes:         "iowx",
+		ChannelModes:      "beIklmnopstv",
+		ChannelParamModes: "bkloveI",
+	}, result)
+
+	// Messages after the welcome block should be ignored
+	result, done = c.Add(irc.MustParseMessage(":irc.example.com 005 lemuria_ CHANTYPES=# :are supported by this server"))
+	assert.Nil(t, result)
+	assert.False(t, done)
+
+	// Missing optional params shouldn't be an issue
+	c.Add(irc.MustParseMessage(":irc.example.com 001 other :Welcome"))
+	result, done = c.Add(irc.MustParseMessage(":irc.example.com 004 other irc.example.com ircd-1.2.3 iow bklmnopstv"))
+	assert.True(t, done)
+	assert.Equal(t, &irc.WelcomeResult{
+		Nick:         "other",
+		Message:      "Welcome",
+		Server:       "irc.example.com",
+		Version:      "ircd-1.2.3",
+		UserModes:    "iow",
+		ChannelModes: "bklmnopstv",
+	}, result)
+}
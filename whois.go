@@ -0,0 +1,162 @@
+package irc
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// These numerics aren't in any RFC, so they aren't in the numerics list, but
+// they are common enough that they are worth handling.
+const (
+	rplWhoisAccount = "330" // ircu
+	rplWhoisSecure  = "671" // Unreal
+)
+
+// WhoisResult contains all the information collected from the replies to a
+// WHOIS command.
+type WhoisResult struct {
+	Nick     string
+	User     string
+	Host     string
+	Realname string
+
+	// Server and ServerInfo come from RPL_WHOISSERVER.
+	Server     string
+	ServerInfo string
+
+	// Idle and SignonTime come from RPL_WHOISIDLE. SignonTime will be the
+	// zero time if the server didn't send it.
+	Idle       time.Duration
+	SignonTime time.Time
+
+	// Channels contains the channels from RPL_WHOISCHANNELS. Note that these
+	// will still have any membership prefixes (like @ or +) attached.
+	Channels []string
+
+	// Account will be empty if the user is not logged in.
+	Account string
+
+	// Away will be empty if the user is not away.
+	Away string
+
+	Operator bool
+	Secure   bool
+}
+
+// WhoisCollector accumulates the many numerics sent in response to a WHOIS
+// command into a single WhoisResult. Replies for multiple nicks may be
+// collected at the same time. It is not safe for concurrent use.
+type WhoisCollector struct {
+	pending map[string]*WhoisResult
+	mapping CaseMapping
+}
+
+// NewWhoisCollector creates a new, empty WhoisCollector. Nicks are compared
+// using the CASEMAPPING from supports, as servers don't always spell the nick
+// the same way in every reply. A nil ISupport may be passed to use the
+// default rfc1459 mapping.
+func NewWhoisCollector(supports ISupport) *WhoisCollector {
+	return &WhoisCollector{
+		pending: make(map[string]*WhoisResult),
+		mapping: supports.CaseMapping(),
+	}
+}
+
+// Reset drops any partially collected results, such as after a disconnect.
+func (c *WhoisCollector) Reset() {
+	c.pending = make(map[string]*WhoisResult)
+}
+
+// Add needs to be called for each incoming message. All non-WHOIS messages
+// will be ignored. Once RPL_ENDOFWHOIS is received for a nick, the collected
+// result will be returned and done will be true. Anything collected for a nick
+// is dropped on ERR_NOSUCHNICK or ERR_NOSUCHSERVER, as it can't be current.
+func (c *WhoisCollector) Add(m *Message) (result *WhoisResult, done bool) { //nolint:cyclop
+	// All WHOIS replies start with the client and the target nick.
+	if len(m.Params) < 2 {
+		return nil, false
+	}
+
+	nick := m.Params[1]
+	key := c.mapping.ToLower(nick)
+
+	switch m.Command {
+	case RPL_ENDOFWHOIS:
+		result = c.get(nick)
+		delete(c.pending, key)
+		return result, true
+	case ERR_NOSUCHNICK, ERR_NOSUCHSERVER:
+		delete(c.pending, key)
+	case RPL_AWAY:
+		// RPL_AWAY is also sent in response to a PRIVMSG, so we only want to
+		// track it if we're in the middle of a WHOIS.
+		if result, ok := c.pending[key]; ok {
+			result.Away = m.Trailing()
+		}
+	case RPL_WHOISUSER:
+		if len(m.Params) >= 6 {
+			result = c.get(nick)
+			result.Nick = nick
+			result.User = m.Params[2]
+			result.Host = m.Params[3]
+			result.Realname = m.Params[5]
+		}
+	case RPL_WHOISSERVER:
+		if len(m.Params) >= 4 {
+			result = c.get(nick)
+			result.Server = m.Params[2]
+			result.ServerInfo = m.Params[3]
+		}
+	case RPL_WHOISOPERATOR:
+		c.get(nick).Operator = true
+	case RPL_WHOISIDLE:
+		c.handleIdle(c.get(nick), m)
+	case RPL_WHOISCHANNELS:
+		result = c.get(nick)
+		result.Channels = append(result.Channels, strings.Fields(m.Trailing())...)
+	case rplWhoisAccount:
+		if len(m.Params) >= 3 {
+			c.get(nick).Account = m.Params[2]
+		}
+	case rplWhoisSecure:
+		c.get(nick).Secure = true
+	}
+
+	return nil, false
+}
+
+// From rfc2812 section 5.1 (Command responses)
+//
+//	317    RPL_WHOISIDLE
+//	       "<nick> <integer> :seconds idle"
+//
+// Most servers also include the signon time after the idle time.
+func (c *WhoisCollector) handleIdle(result *WhoisResult, m *Message) {
+	if len(m.Params) < 4 {
+		return
+	}
+
+	if idle, err := strconv.ParseInt(m.Params[2], 10, 64); err == nil {
+		result.Idle = time.Duration(idle) * time.Second
+	}
+
+	// If there are 5 params, the 4th is the signon time and the 5th is the
+	// text.
+	if len(m.Params) >= 5 {
+		if signon, err := strconv.ParseInt(m.Params[3], 10, 64); err == nil {
+			result.SignonTime = time.Unix(signon, 0)
+		}
+	}
+}
+
+func (c *WhoisCollector) get(nick string) *WhoisResult {
+	key := c.mapping.ToLower(nick)
+
+	result, ok := c.pending[key]
+	if !ok {
+		result = &WhoisResult{Nick: nick}
+		c.pending[key] = result
+	}
+	return result
+}
@@ -0,0 +1,139 @@
+package irc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/a-random-lemurian/go-irc"
+)
+
+func TestWhoisCollector(t *testing.T) {
+	t.Parallel()
+
+	c := irc.NewWhoisCollector(nil)
+
+	lines := []string{
+		":irc.example.com 311 me Bob bob host.example.com * :Bob Smith",
+		":irc.example.com 319 me Bob :@#ops +#chat",
+		":irc.example.com 319 me Bob :#lobby",
+		":irc.example.com 312 me Bob irc.example.com :Example Server",
+		":irc.example.com 301 me Bob :Gone fishing",
+		":irc.example.com 313 me Bob :is an IRC operator",
+		":irc.example.com 671 me Bob :is using a secure connection",
+		":irc.example.com 330 me Bob bob_account :is logged in as",
+		":irc.example.com 317 me Bob 120 1600000000 :seconds idle, signon time",
+	}
+
+	for _, line := range lines {
+		result, done := c.Add(irc.MustParseMessage(line))
+		assert.Nil(t, result)
+		assert.False(t, done)
+	}
+
+	// Unrelated messages should be ignored
+	result, done := c.Add(irc.MustParseMessage(":Bob!bob@host PRIVMSG me :hello"))
+	assert.Nil(t, result)
+	assert.False(t, done)
+
+	result, done = c.Add(irc.MustParseMessage(":irc.example.com 318 me Bob :End of /WHOIS list"))
+	assert.True(t, done)
+	assert.Equal(t, &irc.WhoisResult{
+		Nick:       "Bob",
+		User:       "bob",
+		Host:       "host.example.com",
+		Realname:   "Bob Smith",
+		Server:     "irc.example.com",
+		ServerInfo: "Example Server",
+		Idle:       120 * time.Second,
+		SignonTime: time.Unix(1600000000, 0),
+		Channels:   []string{"@#ops", "+#chat", "#lobby"},
+		Account:    "bob_account",
+		Away:       "Gone fishing",
+		Operator:   true,
+		Secure:     true,
+	}, result)
+
+	// A WHOIS for an unknown nick still needs to complete.
+	result, done = c.Add(irc.MustParseMessage(":irc.example.com 401 me Nobody :No such nick/channel"))
+	assert.Nil(t, result)
+	assert.False(t, done)
+
+	result, done = c.Add(irc.MustParseMessage(":irc.example.com 318 me Nobody :End of /WHOIS list"))
+	assert.True(t, done)
+	assert.Equal(t, &irc.WhoisResult{Nick: "Nobody"}, result)
+
+	// RPL_AWAY outside of a WHOIS should not start one.
+	result, done = c.Add(irc.MustParseMessage(":irc.example.com 301 me Alice :Away"))
+	assert.Nil(t, result)
+	assert.False(t, done)
+
+	result, done = c.Add(irc.MustParseMessage(":irc.example.com 318 me Alice :End of /WHOIS list"))
+	assert.True(t, done)
+	assert.Equal(t, "", result.Away)
+}
+
+func TestWhoisCollectorCaseMapping(t *testing.T) {
+	t.Parallel()
+
+	c := irc.NewWhoisCollector(nil)
+
+	// The server uses the canonical nick in most replies, but the nick as
+	// queried in RPL_ENDOFWHOIS.
+	for _, line := range []string{
+		":irc.example.com 311 me Bob[away] bob host.example.com * :Bob Smith",
+		":irc.example.com 319 me Bob[away] :#lobby",
+		":irc.example.com 301 me bob{AWAY} :Gone fishing",
+	} {
+		result, done := c.Add(irc.MustParseMessage(line))
+		assert.Nil(t, result)
+		assert.False(t, done)
+	}
+
+	result, done := c.Add(irc.MustParseMessage(":irc.example.com 318 me bob{away} :End of /WHOIS list"))
+	assert.True(t, done)
+	assert.Equal(t, &irc.WhoisResult{
+		Nick:     "Bob[away]",
+		User:     "bob",
+		Host:     "host.example.com",
+		Realname: "Bob Smith",
+		Channels: []string{"#lobby"},
+		Away:     "Gone fishing",
+	}, result)
+
+	// Nothing should be left over for the nick.
+	result, done = c.Add(irc.MustParseMessage(":irc.example.com 318 me Bob[away] :End of /WHOIS list"))
+	assert.True(t, done)
+	assert.Equal(t, &irc.WhoisResult{Nick: "Bob[away]"}, result)
+
+	// With ascii casemapping, brackets aren't folded.
+	c = irc.NewWhoisCollector(irc.ISupport{"CASEMAPPING": "ascii"})
+	c.Add(irc.MustParseMessage(":irc.example.com 311 me Bob[away] bob host.example.com * :Bob Smith"))
+
+	result, done = c.Add(irc.MustParseMessage(":irc.example.com 318 me bob{away} :End of /WHOIS list"))
+	assert.True(t, done)
+	assert.Equal(t, &irc.WhoisResult{Nick: "bob{away}"}, result)
+}
+
+func TestWhoisCollectorStale(t *testing.T) {
+	t.Parallel()
+
+	c := irc.NewWhoisCollector(nil)
+
+	// A nick which quits before the WHOIS completes.
+	c.Add(irc.MustParseMessage(":irc.example.com 311 me Bob bob host.example.com * :Bob Smith"))
+	c.Add(irc.MustParseMessage(":irc.example.com 401 me bob :No such nick/channel"))
+
+	result, done := c.Add(irc.MustParseMessage(":irc.example.com 318 me Bob :End of /WHOIS list"))
+	assert.True(t, done)
+	assert.Equal(t, &irc.WhoisResult{Nick: "Bob"}, result)
+
+	// Reset drops anything pending.
+	c.Add(irc.MustParseMessage(":irc.example.com 311 me Alice alice host.example.com * :Alice"))
+	c.Reset()
+
+	result, done = c.Add(irc.MustParseMessage(":irc.example.com 318 me Alice :End of /WHOIS list"))
+	assert.True(t, done)
+	assert.Equal(t, &irc.WhoisResult{Nick: "Alice"}, result)
+}